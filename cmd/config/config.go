@@ -4,6 +4,7 @@ type Configuration struct {
 	Eolymp   Eolymp
 	Polygon  Polygon
 	Telegram Telegram
+	Compiler Compiler
 	Source   string
 	SpaceId  string
 }
@@ -16,8 +17,16 @@ type Eolymp struct {
 }
 
 type Polygon struct {
-	Login    string
-	Password string
+	Login     string
+	Password  string
+	ApiUrl    string
+	ApiKey    string
+	ApiSecret string
+}
+
+type Compiler struct {
+	Cpp   string
+	Flags []string
 }
 
 type Telegram struct {
@@ -37,6 +37,9 @@ func findAndUpdateProblem(bot *tg.BotAPI, msg *tg.Message) {
 			} else {
 				bot.Send(replyToMsg(msg, "Finished"))
 			}
+			if err := db.Flush(); err != nil {
+				log.Printf("Unable to save state: %v", err)
+			}
 			return
 		}
 	}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"github.com/eolymp/polyglot/cmd/store"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Cache runs cache command: list prints cached objects, verify checks objects of the current space in keeper and
+// prune removes entries which can not be reused anymore.
+func Cache(action string) error {
+	list, err := blobs.List()
+	if err != nil {
+		log.Printf("Unable to read blob cache: %v", err)
+		return err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Endpoint != list[j].Endpoint {
+			return list[i].Endpoint < list[j].Endpoint
+		}
+		if list[i].Space != list[j].Space {
+			return list[i].Space < list[j].Space
+		}
+		return list[i].Sha < list[j].Sha
+	})
+
+	switch action {
+	case "", "list":
+		return ListCache(list)
+	case "verify":
+		_, err := VerifyCache(list)
+		return err
+	case "prune":
+		invalid, err := VerifyCache(list)
+		if err != nil {
+			return err
+		}
+
+		// entries without endpoint and space come from cache.json and are never reused
+		for _, blob := range list {
+			if blob.Endpoint == "" {
+				invalid = append(invalid, blob)
+			}
+		}
+
+		for _, blob := range invalid {
+			blobs.Remove(blob)
+		}
+
+		log.Printf("Removed %v cache entries", len(invalid))
+		return nil
+	default:
+		return fmt.Errorf("unknown cache command %#v, use list, verify or prune", action)
+	}
+}
+
+func ListCache(list []*store.Blob) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENDPOINT\tSPACE\tSHA\tKEY\tSIZE\tUPLOADED\tVERIFIED")
+	for _, blob := range list {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", orDash(blob.Endpoint), orDash(blob.Space), blob.Sha, blob.Key, blob.Size, formatTime(blob.UploadedAt), formatTime(blob.VerifiedAt))
+	}
+	return w.Flush()
+}
+
+// VerifyCache checks cached objects of the current endpoint and space, and returns the ones which are missing in
+// keeper or have a different size
+func VerifyCache(list []*store.Blob) ([]*store.Blob, error) {
+	var scoped []*store.Blob
+	for _, blob := range list {
+		if blobs.Scoped(blob) {
+			scoped = append(scoped, blob)
+		}
+	}
+
+	failed := make([]bool, len(scoped))
+	ctx := types.ContextWithJobs(context.Background(), jobs)
+
+	err := types.Parallel(ctx, len(scoped), func(ctx context.Context, i int) error {
+		if err := blobs.Verify(ctx, scoped[i]); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			log.Printf("Object %v (%v) is invalid: %v", scoped[i].Key, scoped[i].Sha, err)
+			failed[i] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var invalid []*store.Blob
+	for i, blob := range scoped {
+		if failed[i] {
+			invalid = append(invalid, blob)
+		}
+	}
+
+	log.Printf("Verified %v cache entries, %v invalid", len(scoped), len(invalid))
+
+	return invalid, nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -2,46 +2,100 @@ package main
 
 import (
 	"compress/flate"
+	"context"
 	"errors"
 	"fmt"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"github.com/eolymp/polyglot/cmd/polygon"
+	"github.com/eolymp/polyglot/cmd/store"
 	"github.com/mholt/archiver"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const DownloadsDir = "downloads"
 
 func UpdateProblem(link string) error {
-	data := GetData()
-	inter, ok := data[link]
-	if !ok {
+	record, ok := GetProblemRecord(link)
+	if !ok || record.ProblemId == "" {
 		return errors.New("not found link in data")
 	}
-	pid := fmt.Sprintf("%v", inter)
+	pid := record.ProblemId
 	return DownloadAndImportProblem(link, &pid)
 }
 
 func DownloadAndImportProblem(link string, pid *string) error {
+	state, known := GetProblemRecord(link)
+	known = known && !forceImport && *pid != "" && state.ProblemId == *pid
+
+	// with Polygon API the revision is known before the package is downloaded
+	if id, err := strconv.Atoi(link); known && err == nil && !buildPackage && conf.Polygon.ApiKey != "" {
+		if problem, err := plg.Problem(context.Background(), id); err == nil && problem.Revision == state.Revision {
+			log.Printf("Problem %v is not modified since revision %v, skipping", link, state.Revision)
+			return nil
+		}
+	}
+
 	path, err := DownloadProblem(link)
 	if err != nil {
 		log.Println(err)
 		return errors.New("failed to download problem")
 	}
 
-	err = ImportProblem(path, pid, false, "polygon")
+	hash, err := FileHash(path + ".zip")
+	if err != nil {
+		return err
+	}
+
+	revision := 0
+	if spec, err := types.ReadPolygonSpecification(path); err == nil {
+		revision = spec.Revision
+	}
+
+	if known && state.Unchanged(revision, hash) {
+		log.Printf("Problem %v is not modified since revision %v, skipping", link, state.Revision)
+		return nil
+	}
 
-	data := GetData()
-	data[link] = *pid
-	SaveData(data)
+	err = ImportProblem(path, pid, false, types.FormatPolygon)
+	if dryRun {
+		return err
+	}
+
+	if serr := db.Update(func(state *store.State) error {
+		p := state.Problem(link)
+		if p.ProblemId != *pid {
+			*p = store.Problem{ProblemId: *pid}
+		}
+		if err == nil {
+			p.Revision = revision
+			p.Hash = hash
+			p.ImportedAt = time.Now()
+		}
+		return nil
+	}); serr != nil {
+		log.Printf("Unable to save problem record: %v", serr)
+		if err == nil {
+			err = serr
+		}
+	}
 
 	return err
 }
 
+// DownloadProblem downloads Polygon package by problem ID using Polygon API, or by problem link using
+// account login and password.
 func DownloadProblem(link string) (path string, err error) {
+	if id, err := strconv.Atoi(link); err == nil {
+		return DownloadProblemById(id)
+	}
+
 	log.Println("Started polygon download")
 	if conf.Polygon.Login == "" || conf.Polygon.Password == "" {
 		return "", fmt.Errorf("no polygon credentials")
@@ -64,6 +118,79 @@ func DownloadProblem(link string) (path string, err error) {
 	return location, nil
 }
 
+func DownloadProblemById(id int) (path string, err error) {
+	log.Println("Started polygon download of problem", id)
+	if conf.Polygon.ApiKey == "" || conf.Polygon.ApiSecret == "" {
+		return "", fmt.Errorf("no polygon api credentials")
+	}
+	if _, err := os.Stat(DownloadsDir); os.IsNotExist(err) {
+		err = os.Mkdir(DownloadsDir, 0777)
+		if err != nil {
+			log.Println("Failed to create dir")
+			return "", err
+		}
+	}
+
+	ctx := context.Background()
+
+	var pkg *polygon.Package
+	if !buildPackage {
+		pkg, err = plg.LatestPackage(ctx, id, true)
+		if err != nil && !errors.Is(err, polygon.ErrPackageNotFound) {
+			return "", err
+		}
+	}
+
+	// the latest package can be built before the last changes of the problem
+	if pkg != nil {
+		problem, err := plg.Problem(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		if pkg.Revision < problem.Revision {
+			log.Printf("Latest package has revision %v, but the problem is at revision %v", pkg.Revision, problem.Revision)
+			pkg = nil
+		}
+	}
+
+	if pkg == nil {
+		log.Println("Building a new package, it may take a while")
+		pkg, err = plg.BuildPackageAndWait(ctx, id, true, false)
+		if err != nil {
+			log.Println("Failed to build package")
+			return "", err
+		}
+	}
+
+	log.Printf("Downloading package %v (revision %v)", pkg.Id, pkg.Revision)
+
+	location := DownloadsDir + "/" + strconv.Itoa(id)
+
+	file, err := os.Create(location + ".zip")
+	if err != nil {
+		return "", err
+	}
+
+	if err = plg.ProblemPackage(ctx, id, pkg.Id, "windows", file); err != nil {
+		_ = file.Close()
+		log.Println("Failed to download from polygon")
+		return "", err
+	}
+
+	// the archive has to be flushed before it is unpacked
+	if err = file.Close(); err != nil {
+		return "", err
+	}
+
+	if err = Unzip(location+".zip", location); err != nil {
+		return "", err
+	}
+
+	log.Println("Finished polygon download")
+	return location, nil
+}
+
 func DownloadFileAndUnzip(URL, login, password, location string) error {
 	response, err := http.PostForm(URL, url.Values{"login": {login}, "password": {password}, "type": {"windows"}})
 	if err != nil {
@@ -90,13 +217,17 @@ func DownloadFileAndUnzip(URL, login, password, location string) error {
 		return err
 	}
 
+	return Unzip(location+".zip", location)
+}
+
+func Unzip(archive, location string) error {
 	if _, err := os.Stat(location); !os.IsNotExist(err) {
 		if err = os.RemoveAll(location); err != nil {
 			return err
 		}
 	}
 
-	if err = os.Mkdir(location, 0777); err != nil {
+	if err := os.Mkdir(location, 0777); err != nil {
 		return err
 	}
 
@@ -108,7 +239,7 @@ func DownloadFileAndUnzip(URL, login, password, location string) error {
 		OverwriteExisting:      true,
 		ImplicitTopLevelFolder: false,
 	}
-	if err = z.Unarchive(location+".zip", location); err != nil {
+	if err := z.Unarchive(archive, location); err != nil {
 		log.Println(err)
 		return err
 	}
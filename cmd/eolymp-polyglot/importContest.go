@@ -3,52 +3,173 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/antchfx/xmlquery"
+	"github.com/eolymp/go-sdk/eolymp/judge"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"github.com/eolymp/polyglot/cmd/store"
 	"log"
 	"net/http"
 	"net/url"
-	"reflect"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"time"
 )
 
 const RepeatNumberProblemUploads = 5
 const TimeToSleep = 5 * time.Minute
 
+const (
+	ContestScoringICPC = "icpc"
+	ContestScoringIOI  = "ioi"
+)
+
+// ContestProblem is a problem of Polygon contest, link is either problem URL or problem ID
+type ContestProblem struct {
+	Link  string
+	Index string
+}
+
 func ImportContest(contestId string) error {
-	data := GetData()
-	problems := GetProblems(contestId)
-	log.Println(problems)
-	var problemList []map[string]interface{}
 	ctx := context.Background()
+
+	name, problems, err := ListContestProblems(ctx, contestId)
+	if err != nil {
+		log.Println("Failed to list contest problems")
+		return err
+	}
+	log.Println(problems)
+
+	// problems created by the previous run are reused
+	existing := map[string]string{}
+	for _, problem := range GetContestProblems(contestId) {
+		existing[problem.Link] = problem.ProblemId
+	}
+
+	if name == "" {
+		name = "Polygon contest " + contestId
+	}
+
+	if dryRun {
+		for _, problem := range problems {
+			if pid, ok := existing[problem.Link]; ok {
+				log.Printf("Problem %v would be attached to the contest as %v", pid, problem.Index)
+			} else {
+				log.Printf("Problem for %v would be created and attached to the contest as %v", problem.Link, problem.Index)
+			}
+		}
+
+		log.Printf("Contest %#v would be created or updated", name)
+		return nil
+	}
+
+	var problemList []*store.ContestProblem
 	for _, problem := range problems {
-		pid, err := CreateProblem(ctx)
+		pid, ok := existing[problem.Link]
+		if !ok {
+			pid, err = CreateProblem(ctx)
+			if err != nil {
+				log.Println("Failed to create problem")
+				return err
+			}
+		}
+		problemList = append(problemList, &store.ContestProblem{ProblemId: pid, Link: problem.Link, Index: problem.Index})
+	}
+
+	if err := db.Update(func(state *store.State) error {
+		state.Contest(contestId).Problems = problemList
+		return nil
+	}); err != nil {
+		log.Printf("Unable to save contest problems: %v", err)
+		return err
+	}
+
+	return SyncContest(ctx, contestId, name, problemList)
+}
+
+// ImportEjudgeContest imports every problem listed in conf/serve.cfg of ejudge contest directory into its own problem
+// and adds them to the contest. Problems created by the previous run are updated.
+func ImportEjudgeContest(dir string) error {
+	ctx := context.Background()
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	config, err := types.ReadServeConfig(filepath.Join(root, "conf", "serve.cfg"))
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]string{}
+	for _, problem := range GetContestProblems(root) {
+		existing[problem.Link] = problem.ProblemId
+	}
+
+	var problemList []*store.ContestProblem
+	for _, problem := range config.Problems() {
+		path := filepath.Join(root, "problems", problem.String("problem_dir", problem.InternalName()))
+		pid := existing[path]
+
+		log.Printf("Importing problem %v from %v", problem.ShortName(), path)
+
+		err := ImportProblem(path, &pid, false, types.FormatEjudge)
+		if dryRun {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// the problem is saved even if the import fails after it is created, so the next run does not create it again
+		if pid != "" {
+			problemList = append(problemList, &store.ContestProblem{ProblemId: pid, Link: path, Index: problem.ShortName()})
+
+			if serr := db.Update(func(state *store.State) error {
+				state.Contest(root).Problems = problemList
+				return nil
+			}); serr != nil {
+				log.Printf("Unable to save contest problems: %v", serr)
+				if err == nil {
+					err = serr
+				}
+			}
+		}
+
 		if err != nil {
-			log.Println("Failed to create problem")
+			log.Printf("Failed to import problem %v", problem.ShortName())
 			return err
 		}
-		problemList = append(problemList, map[string]interface{}{"id": pid, "link": problem})
 	}
-	data[contestId] = problemList
-	SaveData(data)
-	log.Println(data)
-	//UpdateContest(contestId)
-	return nil
+
+	if dryRun {
+		return nil
+	}
+
+	return SyncContest(ctx, root, "Ejudge contest "+filepath.Base(root), problemList)
 }
 
+// UpdateContest imports problems of the contest again, starting from firstProblem. Ejudge contests are given by their
+// directory and are imported again as a whole.
 func UpdateContest(contestId string, firstProblem int) error {
-	data := GetData()
-	t := reflect.ValueOf(data[contestId])
-	for i := firstProblem; i < t.Len(); i++ {
-		m := t.Index(i).Elem()
-		g := make(map[string]string)
-		iter := m.MapRange()
-		for iter.Next() {
-			g[iter.Key().String()] = iter.Value().Elem().String()
-		}
-		pid := g["id"]
-		log.Println(pid, g["link"])
+	if _, err := os.Stat(filepath.Join(contestId, "conf", "serve.cfg")); err == nil {
+		if firstProblem > 0 {
+			return errors.New("--skipproblems is not supported for ejudge contests")
+		}
+		return ImportEjudgeContest(contestId)
+	}
+
+	problems := GetContestProblems(contestId)
+	for i := firstProblem; i < len(problems); i++ {
+		g := problems[i]
+		pid := g.ProblemId
+		log.Println(pid, g.Link)
 		for j := 0; j < RepeatNumberProblemUploads; j++ {
-			if err := DownloadAndImportProblem(g["link"], &pid); err != nil {
+			if err := DownloadAndImportProblem(g.Link, &pid); err != nil {
 				log.Println(err)
 				time.Sleep(TimeToSleep)
 				if j+1 == RepeatNumberProblemUploads {
@@ -63,23 +184,253 @@ func UpdateContest(contestId string, firstProblem int) error {
 	return nil
 }
 
-func GetProblems(contestId string) []string {
-	response, err := http.PostForm("https://polygon.codeforces.com/c/"+contestId+"/contest.xml", url.Values{"login": {conf.Polygon.Login}, "password": {conf.Polygon.Password}, "type": {"windows"}})
+// GetContestProblems returns problems saved for the contest by ImportContest or ImportEjudgeContest
+func GetContestProblems(contestId string) []*store.ContestProblem {
+	var problems []*store.ContestProblem
+
+	err := db.View(func(state *store.State) error {
+		if contest, ok := state.Contests[contestId]; ok {
+			problems = contest.Problems
+		}
+		return nil
+	})
 	if err != nil {
+		log.Printf("Unable to read contest problems: %v", err)
+	}
+
+	return problems
+}
+
+// SyncContest creates Eolymp contest for the Polygon contest, or updates the one created before, and attaches
+// problems to it under their Polygon letters.
+func SyncContest(ctx context.Context, contestId, name string, problems []*store.ContestProblem) error {
+	format := judge.Contest_ICPC
+	switch contestScoring {
+	case ContestScoringICPC:
+	case ContestScoringIOI:
+		format = judge.Contest_IOI
+	default:
+		return fmt.Errorf("unknown contest scoring %#v", contestScoring)
+	}
+
+	id := ""
+	if err := db.View(func(state *store.State) error {
+		if contest, ok := state.Contests[contestId]; ok {
+			id = contest.ContestId
+		}
 		return nil
+	}); err != nil {
+		log.Printf("Unable to read contest record: %v", err)
+		return err
+	}
+
+	if id != "" {
+		out, err := jdg.DescribeContest(ctx, &judge.DescribeContestInput{ContestId: id})
+		if err != nil {
+			log.Printf("Unable to describe contest: %v", err)
+			return err
+		}
+
+		contest := out.GetContest()
+		if contest == nil {
+			return errors.New("contest not found")
+		}
+
+		contest.Name = name
+		contest.Format = format
+
+		if _, err := jdg.UpdateContest(ctx, &judge.UpdateContestInput{ContestId: id, Contest: contest}); err != nil {
+			log.Printf("Unable to update contest: %v", err)
+			return err
+		}
+
+		log.Printf("Updated contest %v", id)
+	} else {
+		out, err := jdg.CreateContest(ctx, &judge.CreateContestInput{Contest: &judge.Contest{Name: name, Format: format}})
+		if err != nil {
+			log.Printf("Unable to create contest: %v", err)
+			return err
+		}
+
+		id = out.ContestId
+
+		if err := db.Update(func(state *store.State) error {
+			state.Contest(contestId).ContestId = id
+			return nil
+		}); err != nil {
+			log.Printf("Unable to save contest record: %v", err)
+			return err
+		}
+
+		log.Printf("Created contest %v", id)
+	}
+
+	attached, err := listContestProblems(ctx, id)
+	if err != nil {
+		log.Printf("Unable to list contest problems: %v", err)
+		return err
+	}
+
+	// problems removed from the source contest are detached, so they do not keep their old indexes
+	listed := map[string]bool{}
+	for _, problem := range problems {
+		listed[problem.ProblemId] = true
+	}
+
+	for base, cp := range attached {
+		if listed[base] {
+			continue
+		}
+
+		if _, err := jdg.DeleteProblem(ctx, &judge.DeleteProblemInput{ContestId: id, ProblemId: cp.Id}); err != nil {
+			log.Printf("Unable to remove problem from contest: %v", err)
+			return err
+		}
+
+		log.Printf("Removed problem %v from contest %v", base, id)
+	}
+
+	var letters []string
+	for _, problem := range problems {
+		letters = append(letters, problem.Index)
+	}
+
+	indexes := ContestProblemIndexes(letters)
+
+	for i, problem := range problems {
+		index := indexes[i]
+		if cp, ok := attached[problem.ProblemId]; ok {
+			if _, err := jdg.UpdateProblem(ctx, &judge.UpdateProblemInput{ContestId: id, ProblemId: cp.Id, Index: index, ScoreByBestTestset: format == judge.Contest_IOI}); err != nil {
+				log.Printf("Unable to update contest problem: %v", err)
+				return err
+			}
+
+			log.Printf("Updated problem %v in contest %v", problem.ProblemId, id)
+		} else {
+			if _, err := jdg.ImportProblem(ctx, &judge.ImportProblemInput{ContestId: id, ImportId: problem.ProblemId, Index: index, ScoreByBestTestset: format == judge.Contest_IOI}); err != nil {
+				log.Printf("Unable to add problem to contest: %v", err)
+				return err
+			}
+
+			log.Printf("Added problem %v to contest %v", problem.ProblemId, id)
+		}
+	}
+
+	return nil
+}
+
+// listContestProblems returns every problem attached to the contest indexed by the ID of the problem it is imported from
+func listContestProblems(ctx context.Context, id string) (map[string]*judge.Problem, error) {
+	attached := map[string]*judge.Problem{}
+
+	for offset := int32(0); ; {
+		out, err := jdg.ListProblems(ctx, &judge.ListProblemsInput{ContestId: id, Offset: offset, Size: 100})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, problem := range out.GetItems() {
+			attached[problem.GetBaseId()] = problem
+		}
+
+		offset += int32(len(out.GetItems()))
+		if len(out.GetItems()) == 0 || offset >= out.GetTotal() {
+			return attached, nil
+		}
+	}
+}
+
+// ContestProblemIndexes converts Polygon letters (A, B, ...) to problem indexes in the contest. If any letter can
+// not be converted (A1, A2, 10 and so on) or letters repeat, problems are numbered by their position instead, so
+// indexes are always unique.
+func ContestProblemIndexes(letters []string) []uint32 {
+	indexes := make([]uint32, len(letters))
+	used := map[uint32]bool{}
+
+	for i, letter := range letters {
+		if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' || used[uint32(letter[0]-'A')+1] {
+			log.Printf("Problems are numbered by position, letters %v can not be used as indexes", letters)
+
+			for j := range letters {
+				indexes[j] = uint32(j + 1)
+			}
+
+			return indexes
+		}
+
+		indexes[i] = uint32(letter[0]-'A') + 1
+		used[indexes[i]] = true
+	}
+
+	return indexes
+}
+
+// ListContestProblems returns contest name and problems. Problems are listed using Polygon API when it is configured,
+// otherwise problem links are fetched from contest.xml using account login and password.
+func ListContestProblems(ctx context.Context, contestId string) (string, []ContestProblem, error) {
+	id, err := strconv.Atoi(contestId)
+	if err != nil || conf.Polygon.ApiKey == "" {
+		return GetContestXml(contestId)
+	}
+
+	out, err := plg.ContestProblems(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var indexes []string
+	for index, problem := range out {
+		if problem.Deleted {
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+
+	sort.Strings(indexes)
+
+	var problems []ContestProblem
+	for _, index := range indexes {
+		problems = append(problems, ContestProblem{Link: strconv.Itoa(out[index].Id), Index: index})
+	}
+
+	// contest name is only available in contest.xml
+	name := ""
+	if conf.Polygon.Login != "" && conf.Polygon.Password != "" {
+		if n, _, err := GetContestXml(contestId); err == nil {
+			name = n
+		}
+	}
+
+	return name, problems, nil
+}
+
+func GetContestXml(contestId string) (string, []ContestProblem, error) {
+	response, err := http.PostForm("https://polygon.codeforces.com/c/"+contestId+"/contest.xml", url.Values{"login": {conf.Polygon.Login}, "password": {conf.Polygon.Password}, "type": {"windows"}})
+	if err != nil {
+		return "", nil, err
 	}
 	defer func() {
 		_ = response.Body.Close()
 	}()
 	buf := new(bytes.Buffer)
-	buf.ReadFrom(response.Body)
+	if _, err := buf.ReadFrom(response.Body); err != nil {
+		return "", nil, err
+	}
 	doc, err := xmlquery.Parse(buf)
 	if err != nil {
-		panic(err)
+		return "", nil, err
 	}
-	var result []string
-	for _, n := range xmlquery.Find(doc, "//contest/problems/problem/@url") {
-		result = append(result, n.InnerText())
+
+	name := ""
+	if n := xmlquery.FindOne(doc, "//contest/names/name[@language='english']/@value"); n != nil {
+		name = n.InnerText()
+	} else if n := xmlquery.FindOne(doc, "//contest/names/name/@value"); n != nil {
+		name = n.InnerText()
+	}
+
+	var result []ContestProblem
+	for _, n := range xmlquery.Find(doc, "//contest/problems/problem") {
+		result = append(result, ContestProblem{Link: n.SelectAttr("url"), Index: n.SelectAttr("index")})
 	}
-	return result
+	return name, result, nil
 }
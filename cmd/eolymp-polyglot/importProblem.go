@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/executor"
 	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
 	"log"
+	"os"
+	"strings"
+	"time"
 )
 
 func ImportProblem(path string, pid *string, skipTests bool, format string) error {
@@ -13,264 +17,375 @@ func ImportProblem(path string, pid *string, skipTests bool, format string) erro
 	var err error
 
 	var imp types.Importer
-	ctx := context.Background()
-	edi := atlas.NewEditorialServiceHttpClient("https://api.eolymp.com/spaces/"+conf.SpaceId+"/problems/"+*pid, client)
+	var pimp *types.PolygonImporter
+	started := time.Now()
+	ctx := types.ContextWithJobs(context.Background(), jobs)
 
-	if format == "eolymp" {
+	if !types.IsFormat(format) {
+		return fmt.Errorf("unknown format %#v, use one of: %v", format, strings.Join(types.Formats, ", "))
+	}
+
+	// eolymp problems are imported by ID, other sources can be archives or links
+	source := path
+	if format != types.FormatEolymp {
+		workspace, cleanup, err := OpenWorkspace(source)
+		if err != nil {
+			return err
+		}
+
+		defer cleanup()
+		path = workspace
+	}
+
+	if format == types.FormatAuto {
+		if format, err = types.DetectFormat(path); err != nil {
+			return err
+		}
+
+		log.Printf("Detected %v format of %v", format, path)
+	}
+
+	if format == types.FormatEolymp {
 		atl := atlas.NewAtlasHttpClient(SpaceIdToLink(conf.Eolymp.SpaceImport), client)
-		imp, err = types.CreateEolympImporter(ctx, path, atl, edi)
-	} else if format == "ejudge" {
+		imp, err = types.CreateEolympImporter(ctx, path, atl, NewEditorialService(conf.Eolymp.SpaceImport, path))
+	} else if format == types.FormatEjudge {
 		imp, err = types.CreateEjudgeImporter(path, ctx, tw, kpr)
-	} else if format == "dots" {
+	} else if format == types.FormatDots {
 		imp, err = types.CreateDotsImporter(path, ctx, tw, kpr)
+	} else if format == types.FormatKattis {
+		imp, err = types.CreateKattisImporter(path, ctx, tw, kpr)
+	} else if format == types.FormatDomjudge {
+		imp, err = types.CreateDomjudgeImporter(path, ctx, tw, kpr)
+	} else if format == types.FormatCms {
+		imp, err = types.CreateCmsImporter(path, ctx, tw, kpr)
+	} else if format == types.FormatTps {
+		imp, err = types.CreateTpsImporter(path, ctx, tw, kpr)
+	} else if format == types.FormatPcms2 {
+		imp, err = types.CreatePcms2Importer(path, ctx, tw, kpr)
 	} else {
-		imp, err = types.CreatePolygonImporter(path, ctx, tw, kpr)
+		pimp, err = types.CreatePolygonImporter(path, ctx, tw, kpr)
+		if err == nil {
+			err = pimp.SetTestsetPolicy(testsetPolicy, GetTestsetSlots(*pid))
+		}
+		if err == nil && !skipTests {
+			err = pimp.GenerateTests(compiler, testCache)
+		}
+		if err == nil && !skipTests && !skipValidation {
+			err = pimp.Validate(compiler)
+		}
+		imp = pimp
 	}
 
 	if err != nil {
 		return err
 	}
 
-	statements := map[string]*atlas.Statement{}
-	editorials := map[string]*atlas.Editorial{}
-	testsets := map[uint32]*atlas.Testset{}
-	tests := map[string]*atlas.Test{}
-
 	// create problem
-	if *pid == "" {
+	exists := *pid != ""
+	if !exists && !dryRun {
 		*pid, err = CreateProblem(ctx)
 		if err != nil {
 			log.Printf("Unable to create problem: %v", err)
 			return err
 		}
-	} else {
-		stout, err := atl.ListStatements(ctx, &atlas.ListStatementsInput{ProblemId: *pid})
-		if err != nil {
-			log.Printf("Unable to list problem statements in Atlas: %v", err)
+	}
+
+	state := NewProblemState()
+	if exists {
+		if state, err = DescribeProblemState(ctx, *pid); err != nil {
 			return err
 		}
+	}
 
-		log.Printf("Found %v existing statements", len(stout.GetItems()))
+	plan, err := BuildPlan(ctx, imp, *pid, state, skipTests)
+	if err != nil {
+		return err
+	}
 
-		for _, s := range stout.GetItems() {
-			statements[s.GetLocale()] = s
+	if dryRun {
+		if planFormat == "json" {
+			return plan.WriteJSON(os.Stdout)
 		}
+		return plan.WriteText(os.Stdout)
+	}
 
-		solout, err := edi.ListEditorials(ctx, &atlas.ListEditorialsInput{})
-		if err != nil {
-			log.Printf("Unable to list problem editorials in Atlas: %v", err)
-			return err
+	err = ApplyPlan(ctx, plan)
+	RecordImport(source, *pid, format, started, err)
+	if err != nil {
+		return err
+	}
+
+	// slots are only stored once the testsets are actually in Atlas
+	if pimp != nil {
+		if slots, err := pimp.TestsetSlots(); err != nil {
+			log.Printf("Unable to save testset slots: %v", err)
+		} else {
+			SaveTestsetSlots(*pid, slots)
 		}
+	}
 
-		log.Printf("Found %v existing editorials", len(solout.GetItems()))
+	plan.LogSummary()
 
-		for _, s := range solout.GetItems() {
-			editorials[s.GetLocale()] = s
-		}
+	log.Printf("Finished")
 
-		tsout, err := atl.ListTestsets(ctx, &atlas.ListTestsetsInput{ProblemId: *pid})
-		if err != nil {
-			log.Printf("Unable to list problem testsets in Atlas: %v", err)
-			return err
-		}
+	return nil
+}
 
-		log.Printf("Found %v existing testsets", len(tsout.GetItems()))
+// ProblemState contains objects which already exist in Atlas, statements and editorials are indexed by locale,
+// testsets by index and tests by "testset index/test index".
+type ProblemState struct {
+	Statements  map[string]*atlas.Statement
+	Editorials  map[string]*atlas.Editorial
+	Testsets    map[uint32]*atlas.Testset
+	Tests       map[string]*atlas.Test
+	Templates   []*atlas.Template
+	Attachments []*atlas.Attachment
+	Verifier    *executor.Verifier
+	Interactor  *executor.Interactor
+}
 
-		for _, ts := range tsout.GetItems() {
-			testsets[ts.GetIndex()] = ts
+func NewProblemState() *ProblemState {
+	return &ProblemState{
+		Statements: map[string]*atlas.Statement{},
+		Editorials: map[string]*atlas.Editorial{},
+		Testsets:   map[uint32]*atlas.Testset{},
+		Tests:      map[string]*atlas.Test{},
+	}
+}
 
-			ttout, err := atl.ListTests(ctx, &atlas.ListTestsInput{TestsetId: ts.GetId(), ProblemId: *pid})
-			if err != nil {
-				log.Printf("Unable to list problem tests in Atlas: %v", err)
-				return err
-			}
+// DescribeProblemState reads all objects of the problem from Atlas
+func DescribeProblemState(ctx context.Context, pid string) (*ProblemState, error) {
+	state := NewProblemState()
 
-			log.Printf("Found %v existing tests in testset %v", len(ttout.GetItems()), ts.Index)
+	stout, err := atl.ListStatements(ctx, &atlas.ListStatementsInput{ProblemId: pid})
+	if err != nil {
+		log.Printf("Unable to list problem statements in Atlas: %v", err)
+		return nil, err
+	}
 
-			for _, tt := range ttout.GetItems() {
-				tests[fmt.Sprint(ts.Index, "/", tt.Index)] = tt
-			}
-		}
+	log.Printf("Found %v existing statements", len(stout.GetItems()))
+
+	for _, s := range stout.GetItems() {
+		state.Statements[s.GetLocale()] = s
 	}
 
-	oldTemplates, err := atl.ListCodeTemplates(ctx, &atlas.ListCodeTemplatesInput{ProblemId: *pid})
+	solout, err := NewEditorialService(conf.SpaceId, pid).ListEditorials(ctx, &atlas.ListEditorialsInput{})
+	if err != nil {
+		log.Printf("Unable to list problem editorials in Atlas: %v", err)
+		return nil, err
+	}
 
-	for _, template := range oldTemplates.GetItems() {
-		_, err = atl.DeleteCodeTemplate(ctx, &atlas.DeleteCodeTemplateInput{TemplateId: template.Id, ProblemId: *pid})
-		if err != nil {
-			log.Printf("Unable to delete code template: %v", err)
-			return err
-		}
+	log.Printf("Found %v existing editorials", len(solout.GetItems()))
+
+	for _, s := range solout.GetItems() {
+		state.Editorials[s.GetLocale()] = s
 	}
 
-	templates, err := imp.GetTemplates(pid)
+	tsout, err := atl.ListTestsets(ctx, &atlas.ListTestsetsInput{ProblemId: pid})
 	if err != nil {
-		return err
+		log.Printf("Unable to list problem testsets in Atlas: %v", err)
+		return nil, err
 	}
 
-	for _, template := range templates {
-		_, err = atl.CreateCodeTemplate(ctx, &atlas.CreateCodeTemplateInput{ProblemId: *pid, Template: template})
+	log.Printf("Found %v existing testsets", len(tsout.GetItems()))
+
+	for _, ts := range tsout.GetItems() {
+		state.Testsets[ts.GetIndex()] = ts
+
+		ttout, err := atl.ListTests(ctx, &atlas.ListTestsInput{TestsetId: ts.GetId(), ProblemId: pid})
 		if err != nil {
-			log.Printf("Unable to create code template: %v", err)
-			return err
+			log.Printf("Unable to list problem tests in Atlas: %v", err)
+			return nil, err
+		}
+
+		log.Printf("Found %v existing tests in testset %v", len(ttout.GetItems()), ts.Index)
+
+		for _, tt := range ttout.GetItems() {
+			state.Tests[fmt.Sprint(ts.Index, "/", tt.Index)] = tt
 		}
-		log.Printf("Added a template for %s", template.Runtime)
 	}
 
-	// set verifier
-	verifier, err := imp.GetVerifier()
+	tmout, err := atl.ListCodeTemplates(ctx, &atlas.ListCodeTemplatesInput{ProblemId: pid})
 	if err != nil {
-		log.Printf("Unable to create E-Olymp verifier: %v", err)
-		return err
+		log.Printf("Unable to list code templates in Atlas: %v", err)
+		return nil, err
 	}
 
-	if _, err = atl.UpdateVerifier(ctx, &atlas.UpdateVerifierInput{ProblemId: *pid, Verifier: verifier}); err != nil {
-		log.Printf("Unable to update problem verifier: %v", err)
-		return err
+	state.Templates = tmout.GetItems()
+
+	atout, err := atl.ListAttachments(ctx, &atlas.ListAttachmentsInput{ProblemId: pid})
+	if err != nil {
+		log.Printf("Unable to list attachments in Atlas: %v", err)
+		return nil, err
 	}
 
-	log.Printf("Updated verifier")
+	state.Attachments = atout.GetItems()
 
-	// set interactor
+	vout, err := atl.DescribeVerifier(ctx, &atlas.DescribeVerifierInput{ProblemId: pid})
+	if err != nil {
+		log.Printf("Unable to describe verifier in Atlas: %v", err)
+		return nil, err
+	}
 
-	if imp.HasInteractor() {
-		interactor, err := imp.GetInteractor()
-		if err != nil {
-			log.Printf("Unable to create E-Olymp interactor: %v", err)
+	state.Verifier = vout.GetVerifier()
+
+	iout, err := atl.DescribeInteractor(ctx, &atlas.DescribeInteractorInput{ProblemId: pid})
+	if err != nil {
+		log.Printf("Unable to describe interactor in Atlas: %v", err)
+		return nil, err
+	}
+
+	state.Interactor = iout.GetInteractor()
+
+	return state, nil
+}
+
+// ApplyPlan performs changes from the plan in Atlas
+func ApplyPlan(ctx context.Context, plan *Plan) error {
+	pid := plan.ProblemId
+
+	for _, change := range plan.Templates {
+		template := change.Template
+		switch change.Action {
+		case ActionDelete:
+			if _, err := atl.DeleteCodeTemplate(ctx, &atlas.DeleteCodeTemplateInput{TemplateId: template.Id, ProblemId: pid}); err != nil {
+				log.Printf("Unable to delete code template: %v", err)
+				return err
+			}
+		case ActionCreate:
+			template.ProblemId = pid
+			if err := types.ResolveUploads(ctx, template); err != nil {
+				log.Printf("Unable to upload template files: %v", err)
+				return err
+			}
+
+			if _, err := atl.CreateCodeTemplate(ctx, &atlas.CreateCodeTemplateInput{ProblemId: pid, Template: template}); err != nil {
+				log.Printf("Unable to create code template: %v", err)
+				return err
+			}
+			log.Printf("Added a template for %s", template.Runtime)
+		}
+	}
+
+	// set verifier
+	if plan.Verifier.Action == ActionUpdate {
+		if _, err := atl.UpdateVerifier(ctx, &atlas.UpdateVerifierInput{ProblemId: pid, Verifier: plan.Verifier.Verifier}); err != nil {
+			log.Printf("Unable to update problem verifier: %v", err)
 			return err
 		}
 
-		if _, err = atl.UpdateInteractor(ctx, &atlas.UpdateInteractorInput{ProblemId: *pid, Interactor: interactor}); err != nil {
+		log.Printf("Updated verifier")
+	} else {
+		log.Printf("Verifier is up-to-date")
+	}
+
+	// set interactor
+	if plan.Interactor == nil {
+		log.Printf("No interactor found")
+	} else if plan.Interactor.Action == ActionUpdate {
+		if _, err := atl.UpdateInteractor(ctx, &atlas.UpdateInteractorInput{ProblemId: pid, Interactor: plan.Interactor.Interactor}); err != nil {
 			log.Printf("Unable to update problem interactor: %v", err)
 			return err
 		}
 
 		log.Printf("Updated interactor")
 	} else {
-		log.Printf("No interactor found")
+		log.Printf("Interactor is up-to-date")
 	}
 
-	if !skipTests {
-		testsetList, err := imp.GetTestsets()
-		if err != nil {
-			log.Println(err)
-			log.Println("Failed to get testsets")
-			return err
-		} else if len(testsetList) > 0 {
-			// create testsets
-
-			for _, group := range testsetList {
-				oldTestset, ok := testsets[group.Name]
-				xts := group.Testset
-				if ok {
-					xts.Id = oldTestset.Id
-				}
-
-				delete(testsets, group.Name)
-				if xts.Id != "" {
-					_, err = atl.UpdateTestset(ctx, &atlas.UpdateTestsetInput{TestsetId: xts.Id, ProblemId: *pid, Testset: xts})
-					if err != nil {
-						log.Printf("Unable to create testset: %v", err)
-						return err
-					}
-
-					log.Printf("Updated testset %v", xts.Id)
-				} else {
-					out, err := atl.CreateTestset(ctx, &atlas.CreateTestsetInput{ProblemId: *pid, Testset: xts})
-					if err != nil {
-						log.Printf("Unable to create testset: %v", err)
-						return err
-					}
-
-					xts.Id = out.Id
-
-					log.Printf("Created testset %v", xts.Id)
-				}
-
-				// upload tests
-
-				for _, xtt := range group.Tests {
-					oldTest, ok := tests[fmt.Sprint(group.Name, "/", xtt.Index)]
-					if ok {
-						xtt.Id = oldTest.Id
-					}
-					delete(tests, fmt.Sprint(group.Name, "/", xtt.Index))
-
-					if xtt.Id == "" {
-						out, err := atl.CreateTest(ctx, &atlas.CreateTestInput{TestsetId: xts.Id, ProblemId: *pid, Test: xtt})
-						if err != nil {
-							log.Printf("Unable to create test: %v", err)
-							return err
-						}
-
-						xtt.Id = out.TestId
-
-						log.Printf("Created test %v", xtt.Id)
-					} else {
-						if _, err := atl.UpdateTest(ctx, &atlas.UpdateTestInput{TestId: xtt.Id, Test: xtt, TestsetId: xts.Id, ProblemId: *pid}); err != nil {
-							log.Printf("Unable to update test: %v", err)
-							return err
-						}
-
-						log.Printf("Updated test %v", xtt.Id)
-					}
-				}
+	// create testsets
+	testsetIds := map[uint32]string{}
+	for _, change := range plan.Testsets {
+		xts := change.Testset
+		switch change.Action {
+		case ActionUpdate:
+			if _, err := atl.UpdateTestset(ctx, &atlas.UpdateTestsetInput{TestsetId: xts.Id, ProblemId: pid, Testset: xts}); err != nil {
+				log.Printf("Unable to create testset: %v", err)
+				return err
+			}
 
+			log.Printf("Updated testset %v", xts.Id)
+		case ActionCreate:
+			out, err := atl.CreateTestset(ctx, &atlas.CreateTestsetInput{ProblemId: pid, Testset: xts})
+			if err != nil {
+				log.Printf("Unable to create testset: %v", err)
+				return err
 			}
+
+			xts.Id = out.Id
+
+			log.Printf("Created testset %v", xts.Id)
 		}
+		testsetIds[xts.Index] = xts.Id
+	}
 
-		// remove unused objects
-		for _, test := range tests {
-			log.Printf("Deleting unused test %v", test.Id)
-			if _, err := atl.DeleteTest(ctx, &atlas.DeleteTestInput{TestsetId: test.TestsetId, TestId: test.Id, ProblemId: *pid}); err != nil {
-				log.Printf("Unable to delete test: %v", err)
+	// upload tests, every change touches a different test, so they can be applied concurrently
+	err := types.Parallel(ctx, len(plan.Tests), func(ctx context.Context, i int) error {
+		change := plan.Tests[i]
+		xtt := change.Test
+		if change.Action == ActionCreate || change.Action == ActionUpdate {
+			if err := types.ResolveUploads(ctx, xtt); err != nil {
+				log.Printf("Unable to upload test: %v", err)
 				return err
 			}
 		}
 
-		for _, testset := range testsets {
-			log.Printf("Deleting unused testset %v", testset.Id)
-			if _, err := atl.DeleteTestset(ctx, &atlas.DeleteTestsetInput{TestsetId: testset.Id, ProblemId: *pid}); err != nil {
-				log.Printf("Unable to delete testset: %v", err)
+		switch change.Action {
+		case ActionCreate:
+			out, err := atl.CreateTest(ctx, &atlas.CreateTestInput{TestsetId: testsetIds[change.Testset], ProblemId: pid, Test: xtt})
+			if err != nil {
+				log.Printf("Unable to create test: %v", err)
 				return err
 			}
-		}
 
-	}
+			xtt.Id = out.TestId
 
-	newStatements := map[string]*atlas.Statement{}
+			log.Printf("Created test %v", xtt.Id)
+		case ActionUpdate:
+			if _, err := atl.UpdateTest(ctx, &atlas.UpdateTestInput{TestId: xtt.Id, Test: xtt, TestsetId: testsetIds[change.Testset], ProblemId: pid}); err != nil {
+				log.Printf("Unable to update test: %v", err)
+				return err
+			}
 
-	statementList, err := imp.GetStatements(conf.Source)
+			log.Printf("Updated test %v", xtt.Id)
+		case ActionDelete:
+			log.Printf("Deleting unused test %v", xtt.Id)
+			if _, err := atl.DeleteTest(ctx, &atlas.DeleteTestInput{TestsetId: xtt.TestsetId, TestId: xtt.Id, ProblemId: pid}); err != nil {
+				log.Printf("Unable to delete test: %v", err)
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		log.Println(err)
-		log.Println("Failed to get statements")
 		return err
 	}
 
-	// get all statements
-	for _, statement := range statementList {
-		newStatements[statement.GetLocale()] = statement
-	}
-
-	for _, statement := range newStatements {
-
-		log.Printf("Updating language %v", statement.Locale)
+	// remove unused testsets
+	for _, change := range plan.Testsets {
+		if change.Action != ActionDelete {
+			continue
+		}
 
-		xs, ok := statements[statement.GetLocale()]
-		if !ok {
-			xs = statement
-		} else {
-			xs.Locale = statement.Locale
-			xs.Title = statement.Title
-			xs.Content = statement.Content
-			xs.Author = statement.Author
-			xs.Source = statement.Source
+		log.Printf("Deleting unused testset %v", change.Testset.Id)
+		if _, err := atl.DeleteTestset(ctx, &atlas.DeleteTestsetInput{TestsetId: change.Testset.Id, ProblemId: pid}); err != nil {
+			log.Printf("Unable to delete testset: %v", err)
+			return err
 		}
+	}
 
-		delete(statements, statement.GetLocale())
+	for _, change := range plan.Statements {
+		xs := change.Statement
+		if change.Action == ActionCreate || change.Action == ActionUpdate {
+			if err := types.ResolveUploads(ctx, xs); err != nil {
+				log.Printf("Unable to upload statement files: %v", err)
+				return err
+			}
+		}
 
-		if xs.Id == "" {
-			out, err := atl.CreateStatement(ctx, &atlas.CreateStatementInput{ProblemId: *pid, Statement: xs})
+		switch change.Action {
+		case ActionCreate:
+			out, err := atl.CreateStatement(ctx, &atlas.CreateStatementInput{ProblemId: pid, Statement: xs})
 			if err != nil {
 				log.Printf("Unable to create statement: %v", err)
 				return err
@@ -279,57 +394,81 @@ func ImportProblem(path string, pid *string, skipTests bool, format string) erro
 			xs.Id = out.StatementId
 
 			log.Printf("Created statement %v", xs.Id)
-		} else {
-			_, err = atl.UpdateStatement(ctx, &atlas.UpdateStatementInput{StatementId: xs.Id, Statement: xs, ProblemId: *pid})
-			if err != nil {
+		case ActionUpdate:
+			if _, err := atl.UpdateStatement(ctx, &atlas.UpdateStatementInput{StatementId: xs.Id, Statement: xs, ProblemId: pid}); err != nil {
 				log.Printf("Unable to create statement: %v", err)
 				return err
 			}
 
 			log.Printf("Updated statement %v", xs.Id)
+		case ActionDelete:
+			log.Printf("Deleting unused statement %v", xs.Id)
+			if _, err := atl.DeleteStatement(ctx, &atlas.DeleteStatementInput{StatementId: xs.Id, ProblemId: pid}); err != nil {
+				log.Printf("Unable to delete statement: %v", err)
+				return err
+			}
 		}
 	}
 
-	// remove unused objects
-	for _, statement := range statements {
-		log.Printf("Deleting unused statement %v", statement.Id)
-		if _, err := atl.DeleteStatement(ctx, &atlas.DeleteStatementInput{StatementId: statement.Id, ProblemId: *pid}); err != nil {
-			log.Printf("Unable to delete statement: %v", err)
-			return err
+	edi := NewEditorialService(conf.SpaceId, pid)
+
+	for _, change := range plan.Editorials {
+		xe := change.Editorial
+		if change.Action == ActionCreate || change.Action == ActionUpdate {
+			if err := types.ResolveUploads(ctx, xe); err != nil {
+				log.Printf("Unable to upload editorial files: %v", err)
+				return err
+			}
 		}
-	}
-	oldAttachments, err := atl.ListAttachments(ctx, &atlas.ListAttachmentsInput{ProblemId: *pid})
-	if err != nil {
-		return err
-	}
-	for _, attachment := range oldAttachments.Items {
-		_, err = atl.DeleteAttachment(ctx, &atlas.DeleteAttachmentInput{
-			ProblemId:    *pid,
-			AttachmentId: attachment.Id,
-		})
-		if err != nil {
-			return err
+
+		switch change.Action {
+		case ActionCreate:
+			out, err := edi.CreateEditorial(ctx, &atlas.CreateEditorialInput{Editorial: xe})
+			if err != nil {
+				log.Printf("Unable to create editorial: %v", err)
+				return err
+			}
+
+			xe.Id = out.EditorialId
+
+			log.Printf("Created editorial %v", xe.Id)
+		case ActionUpdate:
+			if _, err := edi.UpdateEditorial(ctx, &atlas.UpdateEditorialInput{EditorialId: xe.Id, Editorial: xe}); err != nil {
+				log.Printf("Unable to update editorial: %v", err)
+				return err
+			}
+
+			log.Printf("Updated editorial %v", xe.Id)
+		case ActionDelete:
+			log.Printf("Deleting unused editorial %v", xe.Id)
+			if _, err := edi.DeleteEditorial(ctx, &atlas.DeleteEditorialInput{EditorialId: xe.Id}); err != nil {
+				log.Printf("Unable to delete editorial: %v", err)
+				return err
+			}
 		}
-		log.Println(attachment.Name, "has been deleted")
 	}
 
-	attachments, err := imp.GetAttachments(pid)
-	if err != nil {
-		return err
-	}
+	for _, change := range plan.Attachments {
+		attachment := change.Attachment
+		switch change.Action {
+		case ActionDelete:
+			if _, err := atl.DeleteAttachment(ctx, &atlas.DeleteAttachmentInput{ProblemId: pid, AttachmentId: attachment.Id}); err != nil {
+				return err
+			}
+			log.Println(attachment.Name, "has been deleted")
+		case ActionCreate:
+			attachment.ProblemId = pid
+			if err := types.ResolveUploads(ctx, attachment); err != nil {
+				log.Printf("Unable to upload attachment: %v", err)
+				return err
+			}
 
-	for _, attachment := range attachments {
-		_, err = atl.CreateAttachment(ctx, &atlas.CreateAttachmentInput{
-			ProblemId:  *pid,
-			Attachment: attachment,
-		})
-		if err != nil {
-			return err
+			if _, err := atl.CreateAttachment(ctx, &atlas.CreateAttachmentInput{ProblemId: pid, Attachment: attachment}); err != nil {
+				return err
+			}
+			log.Println(attachment.Name, "has been uploaded")
 		}
-		log.Println(attachment.Name, "has been uploaded")
 	}
 
-	log.Printf("Finished")
-
 	return nil
 }
@@ -2,23 +2,52 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/judge"
 	"github.com/eolymp/go-sdk/eolymp/keeper"
 	"github.com/eolymp/go-sdk/eolymp/typewriter"
 	c "github.com/eolymp/polyglot/cmd/config"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
 	"github.com/eolymp/polyglot/cmd/httpx"
 	"github.com/eolymp/polyglot/cmd/oauth"
+	"github.com/eolymp/polyglot/cmd/polygon"
+	"github.com/eolymp/polyglot/cmd/runner"
+	"github.com/eolymp/polyglot/cmd/store"
 	"github.com/spf13/viper"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
+const (
+	StateFile       = "state.json"
+	LegacyDataFile  = "data.json"
+	LegacyCacheFile = "cache.json"
+)
+
 var client httpx.Client
 var atl *atlas.AtlasService
 var tw *typewriter.TypewriterService
 var kpr *keeper.KeeperService
+var jdg *judge.JudgeService
+var plg *polygon.Client
+var db *store.Store
+var blobs *types.BlobCache
 var conf c.Configuration
+var buildPackage bool
+var forceImport bool
+var contestScoring string
+var dryRun bool
+var planFormat string
+var testsetPolicy string
+var jobs int
+var requestRate float64
+var verifyCache bool
+var skipValidation bool
+var compiler *runner.Compiler
+var testCache *types.TestCache
 
 func main() {
 
@@ -35,11 +64,35 @@ func main() {
 	if err != nil {
 		log.Printf("Unable to decode into struct, %v", err)
 	}
+
+	pid := flag.String("id", "", "Problem ID")
+	skipProblems := flag.Int("skipproblems", 0, "Number of first skipped problems")
+	format := flag.String("format", types.FormatAuto, "Problem format: "+strings.Join(types.Formats, ", "))
+	flag.StringVar(&testsetPolicy, "testsets", types.TestsetPolicyMain, "How to import multiple Polygon testsets: main, split or merge")
+	flag.BoolVar(&buildPackage, "build", false, "Build a new Polygon package before downloading")
+	flag.BoolVar(&forceImport, "force", false, "Import Polygon packages even if revision has not changed")
+	flag.StringVar(&contestScoring, "scoring", ContestScoringICPC, "Scoring of the contest created by ic: icpc or ioi")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print changes which would be made to the problem without making them")
+	flag.StringVar(&planFormat, "plan", "text", "Format of the dry-run plan: text or json")
+	flag.IntVar(&jobs, "jobs", 1, "Number of concurrent uploads and Atlas calls")
+	flag.Float64Var(&requestRate, "rate", 0, "Maximum number of Eolymp API requests per second, 0 means no limit")
+	flag.BoolVar(&verifyCache, "verify-cache", false, "Check that cached objects still exist before reusing them")
+	flag.BoolVar(&skipValidation, "skip-validation", false, "Do not run Polygon validator on tests before import")
+	flag.Parse()
+
+	db = store.New(StateFile)
+	if migrated, err := db.Migrate(LegacyDataFile, LegacyCacheFile); err != nil {
+		log.Fatalf("Unable to migrate %v and %v: %v", LegacyDataFile, LegacyCacheFile, err)
+	} else if migrated {
+		log.Printf("Migrated %v and %v to %v", LegacyDataFile, LegacyCacheFile, StateFile)
+	}
+
 	apiLink := conf.Eolymp.ApiUrl
 	spaceLink := SpaceIdToLink(conf.SpaceId)
 
 	client = httpx.NewClient(
 		&http.Client{Timeout: 300 * time.Second},
+		httpx.WithRateLimit(requestRate),
 		httpx.WithCredentials(oauth.PasswordCredentials(
 			oauth.NewClient(conf.Eolymp.ApiUrl),
 			conf.Eolymp.Username,
@@ -55,11 +108,14 @@ func main() {
 
 	tw = typewriter.NewTypewriterHttpClient(apiLink, client)
 	kpr = keeper.NewKeeperHttpClient(apiLink, client)
+	blobs = types.NewBlobCache(db, kpr, apiLink, conf.SpaceId, verifyCache)
+	types.SetBlobCache(blobs)
+	jdg = judge.NewJudgeHttpClient(spaceLink, client)
 
-	pid := flag.String("id", "", "Problem ID")
-	skipProblems := flag.Int("skipproblems", 0, "Number of first skipped problems")
-	format := flag.String("format", "polygon", "Problem Format")
-	flag.Parse()
+	compiler = runner.NewCompiler(conf.Compiler.Cpp, conf.Compiler.Flags)
+	testCache = types.NewTestCache("")
+
+	plg = polygon.NewClient(conf.Polygon.ApiUrl, conf.Polygon.ApiKey, conf.Polygon.ApiSecret)
 
 	command := flag.Arg(0)
 
@@ -68,43 +124,74 @@ func main() {
 		BotStart()
 	case "ic":
 		for i, contestId := 1, flag.Arg(1); contestId != ""; i, contestId = i+1, flag.Arg(i+1) {
-			if err := ImportContest(contestId); err != nil {
-				log.Fatal(err)
+			var err error
+			switch *format {
+			case types.FormatAuto, types.FormatPolygon:
+				err = ImportContest(contestId)
+			case types.FormatEjudge:
+				err = ImportEjudgeContest(contestId)
+			default:
+				err = fmt.Errorf("contests can not be imported in %#v format", *format)
+			}
+			if err != nil {
+				fatal(err)
 			}
 		}
 	case "uc":
 		for i, contestId := 1, flag.Arg(1); contestId != ""; i, contestId = i+1, flag.Arg(i+1) {
 			if err := UpdateContest(contestId, *skipProblems); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		}
 	case "ip":
 		for i, path := 1, flag.Arg(1); path != ""; i, path = i+1, flag.Arg(i+1) {
 			id := *pid
 			if err := ImportProblem(path, &id, false, *format); err != nil {
-				log.Fatal(err)
+				fatal(err)
+			}
+		}
+	case "verify":
+		for i, path := 1, flag.Arg(1); path != ""; i, path = i+1, flag.Arg(i+1) {
+			if err := VerifyProblem(path, *format); err != nil {
+				fatal(err)
 			}
 		}
 	case "dp":
 		for i, link := 1, flag.Arg(1); link != ""; i, link = i+1, flag.Arg(i+1) {
 			id := *pid
 			if err := DownloadAndImportProblem(link, &id); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		}
 	case "up":
 		for i, link := 1, flag.Arg(1); link != ""; i, link = i+1, flag.Arg(i+1) {
 			if err := UpdateProblem(link); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		}
+	case "cache":
+		if err := Cache(flag.Arg(1)); err != nil {
+			fatal(err)
+		}
 	case "export":
 		for i, id := 1, flag.Arg(1); id != ""; i, id = i+1, flag.Arg(i+1) {
 			if err := Export("./export/", id); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		}
 	default:
-		log.Fatal("no command found")
+		fatal("no command found")
+	}
+
+	if err := db.Flush(); err != nil {
+		log.Fatalf("Unable to save state: %v", err)
+	}
+}
+
+// fatal saves deferred state changes, like uploaded objects, and exits
+func fatal(v ...interface{}) {
+	if err := db.Flush(); err != nil {
+		log.Printf("Unable to save state: %v", err)
 	}
+	log.Fatal(v...)
 }
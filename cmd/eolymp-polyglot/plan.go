@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"log"
+	"sort"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	ActionNone   Action = "unchanged"
+)
+
+type VerifierChange struct {
+	Action   Action
+	Verifier *executor.Verifier
+}
+
+type InteractorChange struct {
+	Action     Action
+	Interactor *executor.Interactor
+}
+
+type TestsetChange struct {
+	Action  Action
+	Testset *atlas.Testset
+}
+
+type TestChange struct {
+	Action  Action
+	Testset uint32 // index of the testset
+	Test    *atlas.Test
+}
+
+type StatementChange struct {
+	Action    Action
+	Statement *atlas.Statement
+}
+
+type EditorialChange struct {
+	Action    Action
+	Editorial *atlas.Editorial
+}
+
+type TemplateChange struct {
+	Action   Action
+	Template *atlas.Template
+}
+
+type AttachmentChange struct {
+	Action     Action
+	Attachment *atlas.Attachment
+}
+
+// Plan is a list of changes which have to be made in Atlas to bring the problem in sync with the package.
+// Objects which are already up-to-date are kept in the plan with ActionNone. Interactor is nil when the package
+// does not have one.
+type Plan struct {
+	ProblemId   string
+	Verifier    *VerifierChange
+	Interactor  *InteractorChange
+	Testsets    []*TestsetChange
+	Tests       []*TestChange
+	Statements  []*StatementChange
+	Editorials  []*EditorialChange
+	Templates   []*TemplateChange
+	Attachments []*AttachmentChange
+}
+
+// BuildPlan compares objects produced by the importer with the problem state in Atlas. Files which are not uploaded
+// yet are compared with the existing ones by content, see types.MatchUploads.
+func BuildPlan(ctx context.Context, imp types.Importer, pid string, state *ProblemState, skipTests bool) (*Plan, error) {
+	plan := &Plan{ProblemId: pid}
+
+	for _, template := range state.Templates {
+		plan.Templates = append(plan.Templates, &TemplateChange{Action: ActionDelete, Template: template})
+	}
+
+	templates, err := imp.GetTemplates(&pid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, template := range templates {
+		plan.Templates = append(plan.Templates, &TemplateChange{Action: ActionCreate, Template: template})
+	}
+
+	verifier, err := imp.GetVerifier()
+	if err != nil {
+		log.Printf("Unable to create E-Olymp verifier: %v", err)
+		return nil, err
+	}
+
+	plan.Verifier = &VerifierChange{Action: ActionUpdate, Verifier: verifier}
+	if state.Verifier != nil && proto.Equal(state.Verifier, verifier) {
+		plan.Verifier.Action = ActionNone
+	}
+
+	if imp.HasInteractor() {
+		interactor, err := imp.GetInteractor()
+		if err != nil {
+			log.Printf("Unable to create E-Olymp interactor: %v", err)
+			return nil, err
+		}
+
+		plan.Interactor = &InteractorChange{Action: ActionUpdate, Interactor: interactor}
+		if state.Interactor != nil && proto.Equal(state.Interactor, interactor) {
+			plan.Interactor.Action = ActionNone
+		}
+	}
+
+	if !skipTests {
+		testsetList, err := imp.GetTestsets()
+		if err != nil {
+			log.Println(err)
+			log.Println("Failed to get testsets")
+			return nil, err
+		}
+
+		testsets := map[uint32]*atlas.Testset{}
+		for k, v := range state.Testsets {
+			testsets[k] = v
+		}
+
+		tests := map[string]*atlas.Test{}
+		for k, v := range state.Tests {
+			tests[k] = v
+		}
+
+		for _, group := range testsetList {
+			xts := group.Testset
+			action := ActionCreate
+			if oldTestset, ok := testsets[group.Name]; ok {
+				xts.Id = oldTestset.Id
+				action = ActionUpdate
+				if testsetEqual(oldTestset, xts) {
+					action = ActionNone
+				}
+			}
+
+			delete(testsets, group.Name)
+			plan.Testsets = append(plan.Testsets, &TestsetChange{Action: action, Testset: xts})
+
+			for _, xtt := range group.Tests {
+				key := fmt.Sprint(group.Name, "/", xtt.Index)
+				action := ActionCreate
+				if oldTest, ok := tests[key]; ok {
+					xtt.Id = oldTest.Id
+					action = ActionUpdate
+					if testEqual(ctx, oldTest, xtt) {
+						action = ActionNone
+					}
+				}
+
+				delete(tests, key)
+				plan.Tests = append(plan.Tests, &TestChange{Action: action, Testset: group.Name, Test: xtt})
+			}
+		}
+
+		// remove unused objects
+		for _, key := range types.SortedKeys(tests) {
+			test := tests[key]
+			plan.Tests = append(plan.Tests, &TestChange{Action: ActionDelete, Testset: testsetIndex(state, test.TestsetId), Test: test})
+		}
+
+		for _, testset := range testsets {
+			plan.Testsets = append(plan.Testsets, &TestsetChange{Action: ActionDelete, Testset: testset})
+		}
+
+		sort.SliceStable(plan.Testsets, func(i, j int) bool {
+			return plan.Testsets[i].Action != ActionDelete && plan.Testsets[j].Action == ActionDelete
+		})
+	}
+
+	statementList, err := imp.GetStatements(conf.Source)
+	if err != nil {
+		log.Println(err)
+		log.Println("Failed to get statements")
+		return nil, err
+	}
+
+	newStatements := map[string]*atlas.Statement{}
+	for _, statement := range statementList {
+		newStatements[statement.GetLocale()] = statement
+	}
+
+	for _, locale := range types.SortedKeys(newStatements) {
+		statement := newStatements[locale]
+		xs, ok := state.Statements[locale]
+		if !ok {
+			plan.Statements = append(plan.Statements, &StatementChange{Action: ActionCreate, Statement: statement})
+			continue
+		}
+
+		updated := &atlas.Statement{
+			Id:           xs.Id,
+			ProblemId:    xs.ProblemId,
+			Locale:       statement.Locale,
+			Title:        statement.Title,
+			Content:      statement.Content,
+			DownloadLink: statement.DownloadLink,
+			Author:       statement.Author,
+			Source:       statement.Source,
+		}
+
+		action := ActionUpdate
+		if statementEqual(ctx, xs, updated) {
+			action = ActionNone
+		}
+
+		plan.Statements = append(plan.Statements, &StatementChange{Action: action, Statement: updated})
+	}
+
+	for _, locale := range types.SortedKeys(state.Statements) {
+		if _, ok := newStatements[locale]; !ok {
+			plan.Statements = append(plan.Statements, &StatementChange{Action: ActionDelete, Statement: state.Statements[locale]})
+		}
+	}
+
+	editorialList, err := imp.GetSolutions()
+	if err != nil {
+		log.Println(err)
+		log.Println("Failed to get editorials")
+		return nil, err
+	}
+
+	newEditorials := map[string]*atlas.Editorial{}
+	for _, editorial := range editorialList {
+		newEditorials[editorial.GetLocale()] = editorial
+	}
+
+	for _, locale := range types.SortedKeys(newEditorials) {
+		editorial := newEditorials[locale]
+		xe, ok := state.Editorials[locale]
+		if !ok {
+			plan.Editorials = append(plan.Editorials, &EditorialChange{Action: ActionCreate, Editorial: editorial})
+			continue
+		}
+
+		updated := &atlas.Editorial{
+			Id:           xe.Id,
+			ProblemId:    xe.ProblemId,
+			Locale:       editorial.Locale,
+			Content:      editorial.Content,
+			DownloadLink: xe.DownloadLink,
+		}
+
+		action := ActionUpdate
+		if types.EqualUploads(ctx, updated.GetContent(), xe.GetContent()) {
+			action = ActionNone
+		}
+
+		plan.Editorials = append(plan.Editorials, &EditorialChange{Action: action, Editorial: updated})
+	}
+
+	for _, locale := range types.SortedKeys(state.Editorials) {
+		if _, ok := newEditorials[locale]; !ok {
+			plan.Editorials = append(plan.Editorials, &EditorialChange{Action: ActionDelete, Editorial: state.Editorials[locale]})
+		}
+	}
+
+	for _, attachment := range state.Attachments {
+		plan.Attachments = append(plan.Attachments, &AttachmentChange{Action: ActionDelete, Attachment: attachment})
+	}
+
+	attachments, err := imp.GetAttachments(&pid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range attachments {
+		plan.Attachments = append(plan.Attachments, &AttachmentChange{Action: ActionCreate, Attachment: attachment})
+	}
+
+	return plan, nil
+}
+
+// PlanEntry is a human-readable description of a single change
+type PlanEntry struct {
+	Action  Action `json:"action"`
+	Kind    string `json:"kind"`
+	Key     string `json:"key,omitempty"`
+	Id      string `json:"id,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+// Entries describes every change of the plan in the order they are applied
+func (p *Plan) Entries() []PlanEntry {
+	var entries []PlanEntry
+
+	for _, c := range p.Templates {
+		entries = append(entries, PlanEntry{Action: c.Action, Kind: "template", Key: c.Template.GetRuntime(), Id: c.Template.GetId()})
+	}
+
+	if p.Verifier != nil {
+		entries = append(entries, PlanEntry{Action: p.Verifier.Action, Kind: "verifier", Details: describeVerifier(p.Verifier.Verifier)})
+	}
+
+	if p.Interactor != nil {
+		interactor := p.Interactor.Interactor
+		entries = append(entries, PlanEntry{Action: p.Interactor.Action, Kind: "interactor", Details: fmt.Sprintf("type=%v lang=%v", interactor.GetType(), interactor.GetLang())})
+	}
+
+	for _, c := range p.Testsets {
+		ts := c.Testset
+		entries = append(entries, PlanEntry{
+			Action: c.Action,
+			Kind:   "testset",
+			Key:    fmt.Sprint(ts.GetIndex()),
+			Id:     ts.GetId(),
+			Details: fmt.Sprintf("time=%vms memory=%vMB scoring=%v feedback=%v dependencies=%v",
+				ts.GetTimeLimit(), ts.GetMemoryLimit()/1024/1024, ts.GetScoringMode(), ts.GetFeedbackPolicy(), ts.GetDependencies()),
+		})
+	}
+
+	for _, c := range p.Tests {
+		tt := c.Test
+		entries = append(entries, PlanEntry{
+			Action:  c.Action,
+			Kind:    "test",
+			Key:     fmt.Sprint(c.Testset, "/", tt.GetIndex()),
+			Id:      tt.GetId(),
+			Details: fmt.Sprintf("input=%v answer=%v score=%v example=%v", tt.GetInputObjectId(), tt.GetAnswerObjectId(), tt.GetScore(), tt.GetExample()),
+		})
+	}
+
+	for _, c := range p.Statements {
+		entries = append(entries, PlanEntry{Action: c.Action, Kind: "statement", Key: c.Statement.GetLocale(), Id: c.Statement.GetId(), Details: c.Statement.GetTitle()})
+	}
+
+	for _, c := range p.Editorials {
+		entries = append(entries, PlanEntry{Action: c.Action, Kind: "editorial", Key: c.Editorial.GetLocale(), Id: c.Editorial.GetId()})
+	}
+
+	for _, c := range p.Attachments {
+		entries = append(entries, PlanEntry{Action: c.Action, Kind: "attachment", Key: c.Attachment.GetName(), Id: c.Attachment.GetId()})
+	}
+
+	return entries
+}
+
+// Summary counts changes by action
+func (p *Plan) Summary() map[Action]int {
+	summary := map[Action]int{ActionCreate: 0, ActionUpdate: 0, ActionDelete: 0, ActionNone: 0}
+	for _, e := range p.Entries() {
+		summary[e.Action]++
+	}
+	return summary
+}
+
+// SummaryByKind counts changes by kind of the object and action
+func (p *Plan) SummaryByKind() map[string]map[Action]int {
+	summary := map[string]map[Action]int{}
+	for _, e := range p.Entries() {
+		if summary[e.Kind] == nil {
+			summary[e.Kind] = map[Action]int{}
+		}
+		summary[e.Kind][e.Action]++
+	}
+	return summary
+}
+
+// LogSummary prints number of changed and unchanged objects of every kind
+func (p *Plan) LogSummary() {
+	summary := p.SummaryByKind()
+	for _, kind := range types.SortedKeys(summary) {
+		s := summary[kind]
+		log.Printf("Summary for %v: %v created, %v updated, %v deleted, %v unchanged", kind, s[ActionCreate], s[ActionUpdate], s[ActionDelete], s[ActionNone])
+	}
+}
+
+func (p *Plan) WriteText(w io.Writer) error {
+	problem := p.ProblemId
+	if problem == "" {
+		problem = "(new problem)"
+	}
+
+	if _, err := fmt.Fprintf(w, "Plan for problem %v:\n", problem); err != nil {
+		return err
+	}
+
+	for _, e := range p.Entries() {
+		if e.Action == ActionNone {
+			continue
+		}
+
+		line := fmt.Sprintf("  %-6v %-10v %v", e.Action, e.Kind, e.Key)
+		if e.Id != "" {
+			line += fmt.Sprintf(" (%v)", e.Id)
+		}
+		if e.Details != "" {
+			line += ": " + e.Details
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	summary := p.Summary()
+	_, err := fmt.Fprintf(w, "%v to create, %v to update, %v to delete, %v unchanged\n", summary[ActionCreate], summary[ActionUpdate], summary[ActionDelete], summary[ActionNone])
+	return err
+}
+
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(struct {
+		ProblemId string         `json:"problem_id"`
+		Changes   []PlanEntry    `json:"changes"`
+		Summary   map[Action]int `json:"summary"`
+	}{p.ProblemId, p.Entries(), p.Summary()})
+}
+
+func describeVerifier(v *executor.Verifier) string {
+	switch v.GetType() {
+	case executor.Verifier_TOKENS:
+		return fmt.Sprintf("type=%v precision=%v case_sensitive=%v", v.GetType(), v.GetPrecision(), v.GetCaseSensitive())
+	case executor.Verifier_PROGRAM:
+		return fmt.Sprintf("type=%v lang=%v", v.GetType(), v.GetLang())
+	default:
+		return fmt.Sprintf("type=%v", v.GetType())
+	}
+}
+
+func testEqual(ctx context.Context, a, b *atlas.Test) bool {
+	return a.GetScore() == b.GetScore() &&
+		a.GetExample() == b.GetExample() &&
+		types.MatchUploads(ctx, b.GetInputObjectId(), a.GetInputObjectId()) &&
+		types.MatchUploads(ctx, b.GetAnswerObjectId(), a.GetAnswerObjectId())
+}
+
+func testsetEqual(a, b *atlas.Testset) bool {
+	if len(a.GetDependencies()) != len(b.GetDependencies()) {
+		return false
+	}
+
+	for i, d := range a.GetDependencies() {
+		if b.GetDependencies()[i] != d {
+			return false
+		}
+	}
+
+	return a.GetTimeLimit() == b.GetTimeLimit() &&
+		a.GetCpuLimit() == b.GetCpuLimit() &&
+		a.GetMemoryLimit() == b.GetMemoryLimit() &&
+		a.GetFileSizeLimit() == b.GetFileSizeLimit() &&
+		a.GetScoringMode() == b.GetScoringMode() &&
+		a.GetFeedbackPolicy() == b.GetFeedbackPolicy()
+}
+
+func statementEqual(ctx context.Context, a, b *atlas.Statement) bool {
+	return a.GetTitle() == b.GetTitle() &&
+		a.GetAuthor() == b.GetAuthor() &&
+		a.GetSource() == b.GetSource() &&
+		types.MatchUploads(ctx, b.GetDownloadLink(), a.GetDownloadLink()) &&
+		types.EqualUploads(ctx, b.GetContent(), a.GetContent())
+}
+
+func testsetIndex(state *ProblemState, id string) uint32 {
+	for index, ts := range state.Testsets {
+		if ts.GetId() == id {
+			return index
+		}
+	}
+	return 0
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/ecm"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// planImporter returns objects built by the test
+type planImporter struct {
+	statement *atlas.Statement
+	editorial *atlas.Editorial
+	groups    []*types.Group
+}
+
+func (imp planImporter) GetVerifier() (*executor.Verifier, error) {
+	return &executor.Verifier{Type: executor.Verifier_TOKENS, CaseSensitive: true}, nil
+}
+
+func (imp planImporter) HasInteractor() bool {
+	return false
+}
+
+func (imp planImporter) GetInteractor() (*executor.Interactor, error) {
+	return nil, nil
+}
+
+func (imp planImporter) GetStatements(string) ([]*atlas.Statement, error) {
+	return []*atlas.Statement{imp.statement}, nil
+}
+
+func (imp planImporter) GetSolutions() ([]*atlas.Editorial, error) {
+	return []*atlas.Editorial{imp.editorial}, nil
+}
+
+func (imp planImporter) GetTestsets() ([]*types.Group, error) {
+	return imp.groups, nil
+}
+
+func (imp planImporter) GetTemplates(*string) ([]*atlas.Template, error) {
+	return nil, nil
+}
+
+func (imp planImporter) GetAttachments(*string) ([]*atlas.Attachment, error) {
+	return nil, nil
+}
+
+func TestBuildPlanUnchangedProblem(t *testing.T) {
+	// objects and assets uploaded by the previous import, which is not in the blob cache
+	objects := map[string]string{"input-1": "plan 1 2\n", "answer-1": "plan 3\n", "input-2": "plan 2 2\n", "answer-2": "plan 4\n"}
+	assets := map[string]string{"/assets/picture.png": "plan picture", "/assets/statement.pdf": "plan pdf"}
+
+	var uploads int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			atomic.AddInt32(&uploads, 1)
+			fmt.Fprint(rw, `{"key":"uploaded","link":"uploaded"}`)
+			return
+		}
+
+		if data, ok := assets[req.URL.Path]; ok {
+			fmt.Fprint(rw, data)
+			return
+		}
+
+		key := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/objects/"), "/data")
+		data, ok := objects[key]
+		if !ok {
+			http.NotFound(rw, req)
+			return
+		}
+
+		if strings.HasSuffix(req.URL.Path, "/data") {
+			fmt.Fprintf(rw, `{"data":%q,"size":%v}`, base64.StdEncoding.EncodeToString([]byte(data)), len(data))
+			return
+		}
+
+		fmt.Fprintf(rw, `{"size":%v}`, len(data))
+	}))
+	defer srv.Close()
+
+	kpr := keeper.NewKeeperHttpClient(srv.URL, srv.Client())
+	tw := typewriter.NewTypewriterHttpClient(srv.URL, srv.Client())
+
+	object := func(data string) string {
+		key, err := types.MakeObjectByData([]byte(data), kpr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	picture := types.UploadAsset(tw, "picture.png", []byte("plan picture"))
+	imp := planImporter{
+		statement: &atlas.Statement{
+			Locale:       "en",
+			Title:        "Sum",
+			Content:      &ecm.Content{Value: &ecm.Content_Latex{Latex: "Find a + b.\n\\includegraphics{" + picture + "}\n"}},
+			DownloadLink: types.UploadAsset(tw, "statement.pdf", []byte("plan pdf")),
+		},
+		editorial: &atlas.Editorial{
+			Locale:  "en",
+			Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: "\\includegraphics{" + picture + "} Just add."}},
+		},
+		groups: []*types.Group{{
+			Name:    1,
+			Testset: &atlas.Testset{Index: 1, TimeLimit: 1000, MemoryLimit: 256 << 20},
+			Tests: []*atlas.Test{
+				{Index: 1, Score: 50, InputObjectId: object("plan 1 2\n"), AnswerObjectId: object("plan 3\n")},
+				{Index: 2, Score: 50, InputObjectId: object("plan 2 2\n"), AnswerObjectId: object("plan 4\n")},
+			},
+		}},
+	}
+
+	state := NewProblemState()
+	state.Verifier = &executor.Verifier{Type: executor.Verifier_TOKENS, CaseSensitive: true}
+	state.Statements["en"] = &atlas.Statement{
+		Id:           "statement",
+		Locale:       "en",
+		Title:        "Sum",
+		Content:      &ecm.Content{Value: &ecm.Content_Latex{Latex: "Find a + b.\n\\includegraphics{" + srv.URL + "/assets/picture.png}\n"}},
+		DownloadLink: srv.URL + "/assets/statement.pdf",
+	}
+	state.Editorials["en"] = &atlas.Editorial{
+		Id:      "editorial",
+		Locale:  "en",
+		Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: "\\includegraphics{" + srv.URL + "/assets/picture.png} Just add."}},
+	}
+	state.Testsets[1] = &atlas.Testset{Id: "testset", Index: 1, TimeLimit: 1000, MemoryLimit: 256 << 20}
+	state.Tests["1/1"] = &atlas.Test{Id: "test-1", TestsetId: "testset", Index: 1, Score: 50, InputObjectId: "input-1", AnswerObjectId: "answer-1"}
+	state.Tests["1/2"] = &atlas.Test{Id: "test-2", TestsetId: "testset", Index: 2, Score: 50, InputObjectId: "input-2", AnswerObjectId: "answer-2"}
+
+	plan, err := BuildPlan(context.Background(), imp, "problem", state, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range plan.Entries() {
+		if e.Action != ActionNone {
+			t.Errorf("Expected no changes, got %v %v %v: %v", e.Action, e.Kind, e.Key, e.Details)
+		}
+	}
+
+	// placeholders are resolved to the existing objects, so nothing is uploaded if the plan is applied
+	for _, c := range plan.Tests {
+		if err := types.ResolveUploads(context.Background(), c.Test); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := plan.Tests[1].Test.GetAnswerObjectId(); got != "answer-2" {
+		t.Errorf("Answer of test 2 is resolved to %#v, expected the existing object", got)
+	}
+
+	if n := atomic.LoadInt32(&uploads); n != 0 {
+		t.Errorf("Expected nothing to be uploaded, got %v uploads", n)
+	}
+
+	// the test with different content of the same size is updated
+	changed := NewProblemState()
+	changed.Tests["1/1"] = &atlas.Test{Id: "test-1", Index: 1, Score: 50, InputObjectId: "input-2", AnswerObjectId: "answer-1"}
+
+	imp.groups[0].Tests = []*atlas.Test{{Index: 1, Score: 50, InputObjectId: object("plan 1 3\n"), AnswerObjectId: object("plan 3\n")}}
+	plan, err = BuildPlan(context.Background(), imp, "problem", changed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Tests) != 1 || plan.Tests[0].Action != ActionUpdate {
+		t.Errorf("Expected the changed test to be updated, got %+v", plan.Tests)
+	}
+}
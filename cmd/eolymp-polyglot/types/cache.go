@@ -0,0 +1,174 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/polyglot/cmd/store"
+	"log"
+	"time"
+)
+
+var ErrBlobMissing = errors.New("object does not exist")
+var ErrBlobSize = errors.New("object size does not match")
+
+// BlobCache remembers objects uploaded to keeper, so the same file is not uploaded twice. Entries are scoped by
+// API endpoint and space, and can be verified against keeper before they are reused.
+type BlobCache struct {
+	db       *store.Store
+	kpr      *keeper.KeeperService
+	endpoint string
+	space    string
+	verify   bool
+}
+
+var blobs *BlobCache
+
+func NewBlobCache(db *store.Store, kpr *keeper.KeeperService, endpoint, space string, verify bool) *BlobCache {
+	return &BlobCache{db: db, kpr: kpr, endpoint: endpoint, space: space, verify: verify}
+}
+
+// SetBlobCache sets cache used by UploadObject
+func SetBlobCache(cache *BlobCache) {
+	blobs = cache
+}
+
+// Lookup returns key of the object with given SHA-1 and size uploaded before. With verification enabled the object
+// is checked in keeper first and the entry is dropped if the object is gone.
+func (c *BlobCache) Lookup(ctx context.Context, sha string, size int64) (string, bool) {
+	var blob *store.Blob
+	err := c.db.View(func(state *store.State) error {
+		if b, ok := state.Blob(c.endpoint, c.space, sha); ok {
+			blob = b
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to read blob cache: %v", err)
+		return "", false
+	}
+
+	if blob == nil || (blob.Size != 0 && blob.Size != size) {
+		return "", false
+	}
+
+	if c.verify {
+		if err := c.Verify(ctx, blob); err != nil {
+			log.Printf("Cached object %v can not be reused: %v", blob.Key, err)
+			c.Remove(blob)
+			return "", false
+		}
+	}
+
+	return blob.Key, true
+}
+
+// Save remembers uploaded object
+func (c *BlobCache) Save(sha, key string, size int64) {
+	log.Println("Set", sha, key)
+	err := c.db.Defer(func(state *store.State) error {
+		state.AddBlob(&store.Blob{
+			Endpoint:   c.endpoint,
+			Space:      c.space,
+			Sha:        sha,
+			Key:        key,
+			Size:       size,
+			UploadedAt: time.Now(),
+		})
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to save blob cache: %v", err)
+	}
+}
+
+// LookupAsset returns link of the typewriter asset with given SHA-1 and size uploaded before
+func (c *BlobCache) LookupAsset(sha string, size int64) (string, bool) {
+	var link string
+	err := c.db.View(func(state *store.State) error {
+		if a, ok := state.Asset(c.endpoint, c.space, sha); ok && (a.Size == 0 || a.Size == size) {
+			link = a.Key
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to read blob cache: %v", err)
+		return "", false
+	}
+
+	return link, link != ""
+}
+
+// SaveAsset remembers uploaded typewriter asset
+func (c *BlobCache) SaveAsset(sha, link string, size int64) {
+	err := c.db.Defer(func(state *store.State) error {
+		state.AddAsset(&store.Blob{
+			Endpoint:   c.endpoint,
+			Space:      c.space,
+			Sha:        sha,
+			Key:        link,
+			Size:       size,
+			UploadedAt: time.Now(),
+		})
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to save blob cache: %v", err)
+	}
+}
+
+func (c *BlobCache) Remove(blob *store.Blob) {
+	err := c.db.Defer(func(state *store.State) error {
+		state.RemoveBlob(blob)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to update blob cache: %v", err)
+	}
+}
+
+// List returns all cached entries, including the ones of other endpoints and spaces
+func (c *BlobCache) List() ([]*store.Blob, error) {
+	var list []*store.Blob
+	err := c.db.View(func(state *store.State) error {
+		for _, blob := range state.Blobs {
+			list = append(list, blob)
+		}
+		return nil
+	})
+	return list, err
+}
+
+// Scoped reports whether the entry belongs to the endpoint and space of the cache
+func (c *BlobCache) Scoped(blob *store.Blob) bool {
+	return blob.Scoped(c.endpoint, c.space)
+}
+
+// Verify checks that cached object still exists in keeper and has the recorded size
+func (c *BlobCache) Verify(ctx context.Context, blob *store.Blob) error {
+	if !c.Scoped(blob) {
+		return fmt.Errorf("object belongs to %#v space %#v", blob.Endpoint, blob.Space)
+	}
+
+	out, err := c.kpr.DescribeObject(ctx, &keeper.DescribeObjectInput{Key: blob.Key})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlobMissing, err)
+	}
+
+	if blob.Size != 0 && int64(out.GetSize()) != blob.Size {
+		return fmt.Errorf("%w: recorded %v, actual %v", ErrBlobSize, blob.Size, out.GetSize())
+	}
+
+	err = c.db.Defer(func(state *store.State) error {
+		if b, ok := state.Blob(blob.Endpoint, blob.Space, blob.Sha); ok {
+			b.VerifiedAt = time.Now()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to update blob cache: %v", err)
+	}
+
+	return nil
+}
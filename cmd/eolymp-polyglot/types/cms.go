@@ -0,0 +1,526 @@
+package types
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/ecm"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CmsImporter imports tasks in CMS (Italian) format: task.yaml, input/ and output/, gen/GEN, check/ or cor/ with the
+// checker, sol/ with solutions and statement/
+type CmsImporter struct {
+	Importer
+	spec    *CmsSpecification
+	path    string
+	context context.Context
+	ts      *typewriter.TypewriterService
+	kpr     *keeper.KeeperService
+}
+
+// CmsSpecification is task.yaml of the task
+type CmsSpecification struct {
+	Name                string      `yaml:"name"`
+	Title               string      `yaml:"title"`
+	TimeLimit           float64     `yaml:"time_limit"`
+	MemoryLimit         int         `yaml:"memory_limit"`
+	InputCount          int         `yaml:"n_input"`
+	ScoreType           string      `yaml:"score_type"`
+	ScoreTypeParameters interface{} `yaml:"score_type_parameters"`
+	PublicTestcases     string      `yaml:"public_testcases"`
+	PrimaryLanguage     string      `yaml:"primary_language"`
+}
+
+// CmsSubtask is a group of tests with a score
+type CmsSubtask struct {
+	Score float64
+	Tests []int
+}
+
+const (
+	CmsScoreSum      = "Sum"
+	CmsScoreGroupMin = "GroupMin"
+	CmsScoreGroupMul = "GroupMul"
+)
+
+var cmsSubtaskComment = regexp.MustCompile(`^#\s*ST:\s*([0-9.]+)`)
+
+func CreateCmsImporter(path string, context context.Context, ts *typewriter.TypewriterService, kpr *keeper.KeeperService) (*CmsImporter, error) {
+	importer := new(CmsImporter)
+	importer.path = path
+	importer.context = context
+	importer.ts = ts
+	importer.kpr = kpr
+
+	data, err := ioutil.ReadFile(filepath.Join(path, "task.yaml"))
+	if err != nil {
+		log.Printf("Unable to read task.yaml: %v", err)
+		return nil, err
+	}
+
+	importer.spec = &CmsSpecification{}
+	if err := yaml.Unmarshal(data, importer.spec); err != nil {
+		log.Printf("Unable to parse task.yaml: %v", err)
+		return nil, err
+	}
+
+	if importer.spec.ScoreType == "" {
+		importer.spec.ScoreType = CmsScoreSum
+	}
+
+	return importer, nil
+}
+
+// GetVerifier imports checker from check/ or cor/, C++ checkers are wrapped into testlib adapter
+func (imp CmsImporter) GetVerifier() (*executor.Verifier, error) {
+	for _, dir := range []string{"check", "cor"} {
+		lang, source, ok, err := readProgram(filepath.Join(imp.path, dir), "checker", "correttore")
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if !strings.HasPrefix(lang, "cpp") {
+			return nil, fmt.Errorf("CMS checkers in %v are not supported, only C++ checkers can be adapted to testlib", lang)
+		}
+
+		return &executor.Verifier{Type: executor.Verifier_PROGRAM, Source: cmsCheckerAdapter(source), Lang: lang}, nil
+	}
+
+	// white-diff comparison of CMS
+	return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: true}, nil
+}
+
+// HasInteractor reports whether the task is a communication task with a manager
+func (imp CmsImporter) HasInteractor() bool {
+	_, _, ok, _ := readProgram(filepath.Join(imp.path, "check"), "manager")
+	return ok
+}
+
+// GetInteractor fails, CMS managers talk to solutions through FIFOs and print the score like checkers do, which can
+// not be mapped to testlib interactor
+func (imp CmsImporter) GetInteractor() (*executor.Interactor, error) {
+	return nil, errors.New("communication tasks with a manager are not supported")
+}
+
+// readProgram reads source file of the directory whose name starts with one of the prefixes, local includes are inlined
+func readProgram(dir string, prefixes ...string) (string, string, bool, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", "", false, nil
+	}
+
+	for _, prefix := range prefixes {
+		for _, file := range files {
+			lang, ok := LanguageByExtension(file.Name())
+			if !ok || file.IsDir() || !strings.HasPrefix(file.Name(), prefix) {
+				continue
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+			if err != nil {
+				return "", "", false, err
+			}
+
+			log.Printf("Using %v", filepath.Join(dir, file.Name()))
+			return lang, InlineIncludes(dir, string(data)), true, nil
+		}
+	}
+
+	return "", "", false, nil
+}
+
+// GetStatements reads LaTeX statements from statement/ (statement/<language>.tex, testo/testo.tex), PDF statement
+// is uploaded when there is no LaTeX source
+func (imp CmsImporter) GetStatements(source string) ([]*atlas.Statement, error) {
+	title := imp.spec.Title
+	if title == "" {
+		title = imp.spec.Name
+	}
+
+	var statements []*atlas.Statement
+	for _, dir := range []string{"statement", "testo"} {
+		files, err := ioutil.ReadDir(filepath.Join(imp.path, dir))
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			if filepath.Ext(file.Name()) != ".tex" {
+				continue
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join(imp.path, dir, file.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			content, err := UpdateContentWithPictures(imp.context, imp.ts, string(data), filepath.Join(imp.path, dir)+"/")
+			if err != nil {
+				return nil, err
+			}
+
+			statements = append(statements, &atlas.Statement{
+				Locale:  imp.statementLocale(file.Name()),
+				Title:   title,
+				Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: content}},
+				Source:  source,
+			})
+		}
+
+		if len(statements) > 0 {
+			return statements, nil
+		}
+
+		for _, name := range []string{"statement.pdf", "testo.pdf"} {
+			statement, err := UploadPdfStatement(imp.context, imp.ts, filepath.Join(imp.path, dir, name))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+
+			statement.Locale = imp.statementLocale(name)
+			statement.Title = title
+			statement.Source = source
+
+			return []*atlas.Statement{statement}, nil
+		}
+	}
+
+	return statements, nil
+}
+
+// statementLocale returns locale by file name (english.tex, en.tex), Italian testo files and unknown names get
+// primary language of the task
+func (imp CmsImporter) statementLocale(name string) string {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	if locale, err := MakeLocale(base); err == nil {
+		return locale
+	}
+
+	if len(base) == 2 {
+		return base
+	}
+
+	if base == "testo" {
+		return "it"
+	}
+
+	if imp.spec.PrimaryLanguage != "" {
+		return imp.spec.PrimaryLanguage
+	}
+
+	return "en"
+}
+
+func (imp CmsImporter) GetSolutions() ([]*atlas.Editorial, error) {
+	return nil, nil
+}
+
+// Subtasks returns subtasks from "# ST: score" comments of gen/GEN, or from score_type_parameters of task.yaml
+func (imp CmsImporter) Subtasks(count int) ([]CmsSubtask, error) {
+	subtasks, err := imp.subtasks(count)
+	if err != nil {
+		return nil, err
+	}
+
+	// gen/GEN and test counts of score_type_parameters may list more tests than there are
+	for i, subtask := range subtasks {
+		for _, t := range subtask.Tests {
+			if t >= count {
+				return nil, fmt.Errorf("subtask %v refers to test %v, but only %v tests are found: check n_input, score_type_parameters in task.yaml and gen/GEN", i+1, t, count)
+			}
+		}
+	}
+
+	return subtasks, nil
+}
+
+func (imp CmsImporter) subtasks(count int) ([]CmsSubtask, error) {
+	if subtasks, ok, err := imp.subtasksFromGen(); err != nil || ok {
+		return subtasks, err
+	}
+
+	switch params := imp.spec.ScoreTypeParameters.(type) {
+	case int, float64:
+		// Sum score type, every test has the same score
+		var tests []int
+		for i := 0; i < count; i++ {
+			tests = append(tests, i)
+		}
+		return []CmsSubtask{{Score: toFloat(params) * float64(count), Tests: tests}}, nil
+	case []interface{}:
+		var subtasks []CmsSubtask
+		next := 0
+		for _, p := range params {
+			pair, ok := p.([]interface{})
+			if !ok || len(pair) != 2 {
+				return nil, fmt.Errorf("unsupported score_type_parameters item %v", p)
+			}
+
+			subtask := CmsSubtask{Score: toFloat(pair[0])}
+			switch v := pair[1].(type) {
+			case int:
+				for i := 0; i < v; i++ {
+					subtask.Tests = append(subtask.Tests, next+i)
+				}
+				next += v
+			case string:
+				re, err := regexp.Compile("^(?:" + v + ")$")
+				if err != nil {
+					return nil, fmt.Errorf("invalid subtask regexp %#v: %w", v, err)
+				}
+				for i := 0; i < count; i++ {
+					if re.MatchString(fmt.Sprintf("%03d", i)) || re.MatchString(strconv.Itoa(i)) {
+						subtask.Tests = append(subtask.Tests, i)
+					}
+				}
+			default:
+				return nil, fmt.Errorf("unsupported score_type_parameters item %v", p)
+			}
+			subtasks = append(subtasks, subtask)
+		}
+		return subtasks, nil
+	}
+
+	// no subtasks, whole task is worth 100 points
+	var tests []int
+	for i := 0; i < count; i++ {
+		tests = append(tests, i)
+	}
+	return []CmsSubtask{{Score: 100, Tests: tests}}, nil
+}
+
+// subtasksFromGen reads gen/GEN, every line which is not a comment is a test, "# ST: score" starts a new subtask
+func (imp CmsImporter) subtasksFromGen() ([]CmsSubtask, bool, error) {
+	file, err := os.Open(filepath.Join(imp.path, "gen", "GEN"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var subtasks []CmsSubtask
+	test := 0
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if m := cmsSubtaskComment.FindStringSubmatch(line); m != nil {
+			score, _ := strconv.ParseFloat(m[1], 64)
+			subtasks = append(subtasks, CmsSubtask{Score: score})
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#COPY:") {
+			continue
+		}
+
+		if len(subtasks) == 0 {
+			return nil, false, nil
+		}
+
+		subtasks[len(subtasks)-1].Tests = append(subtasks[len(subtasks)-1].Tests, test)
+		test++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, false, err
+	}
+
+	return subtasks, len(subtasks) > 0, nil
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// testCount returns number of tests from task.yaml or by counting files in input/
+func (imp CmsImporter) testCount() int {
+	if imp.spec.InputCount > 0 {
+		return imp.spec.InputCount
+	}
+
+	count := 0
+	for {
+		if _, err := os.Stat(filepath.Join(imp.path, "input", fmt.Sprintf("input%d.txt", count))); err != nil {
+			return count
+		}
+		count++
+	}
+}
+
+func (imp CmsImporter) GetTestsets() ([]*Group, error) {
+	count := imp.testCount()
+	if count == 0 {
+		if _, err := os.Stat(filepath.Join(imp.path, "gen", "GEN")); err == nil {
+			return nil, errors.New("no tests found in input/, the task only has generators in gen/GEN: generate the tests with cmsMake and import the task again")
+		}
+		return nil, errors.New("no tests found in input/")
+	}
+
+	var paths []TestPath
+	for i := 0; i < count; i++ {
+		paths = append(paths, TestPath{
+			input:  filepath.Join(imp.path, "input", fmt.Sprintf("input%d.txt", i)),
+			output: filepath.Join(imp.path, "output", fmt.Sprintf("output%d.txt", i)),
+		})
+	}
+
+	tests, err := UploadTestPaths(imp.context, imp.kpr, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	subtasks, err := imp.Subtasks(count)
+	if err != nil {
+		return nil, err
+	}
+
+	timeLimit := uint32(math.Round(imp.spec.TimeLimit * 1000))
+	if timeLimit == 0 {
+		log.Println("Time limit is not set in task.yaml, using 1 second")
+		timeLimit = 1000
+	}
+
+	memoryLimit := uint64(imp.spec.MemoryLimit) * 1024 * 1024
+	if memoryLimit == 0 {
+		memoryLimit = 256 * 1024 * 1024
+	}
+
+	var groups []*Group
+	index := uint32(1)
+	for si, subtask := range subtasks {
+		if len(subtask.Tests) == 0 {
+			log.Printf("Subtask %v has no tests, skipping", si+1)
+			continue
+		}
+
+		xts := &atlas.Testset{
+			Index:          index,
+			TimeLimit:      timeLimit,
+			MemoryLimit:    memoryLimit,
+			FileSizeLimit:  536870912,
+			ScoringMode:    atlas.ScoringMode_EACH,
+			FeedbackPolicy: atlas.FeedbackPolicy_COMPLETE,
+		}
+
+		// zero score subtask goes first as examples, the same way Polygon group 0 does
+		examples := si == 0 && subtask.Score == 0
+		if examples {
+			xts.Index = 0
+		} else {
+			index++
+		}
+
+		switch imp.spec.ScoreType {
+		case CmsScoreGroupMin:
+			xts.ScoringMode = atlas.ScoringMode_WORST
+		case CmsScoreGroupMul:
+			xts.ScoringMode = atlas.ScoringMode_ALL
+		}
+
+		// subtask depends on earlier subtasks whose tests it contains
+		for pi := 0; pi < si; pi++ {
+			if len(subtasks[pi].Tests) > 0 && subtasks[pi].Score > 0 && containsAll(subtask.Tests, subtasks[pi].Tests) {
+				xts.Dependencies = append(xts.Dependencies, groupIndexOf(subtasks, pi))
+			}
+		}
+
+		group := &Group{Testset: xts, Name: xts.Index}
+		for ti, t := range subtask.Tests {
+			xtt := &atlas.Test{
+				Index:          int32(ti + 1),
+				Example:        examples,
+				InputObjectId:  tests[t].InputObjectId,
+				AnswerObjectId: tests[t].AnswerObjectId,
+			}
+
+			if xts.ScoringMode == atlas.ScoringMode_WORST {
+				xtt.Score = float32(subtask.Score)
+			} else {
+				xtt.Score = float32(subtask.Score / float64(len(subtask.Tests)))
+			}
+
+			group.Tests = append(group.Tests, xtt)
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// groupIndexOf returns testset index of the subtask as assigned by GetTestsets
+func groupIndexOf(subtasks []CmsSubtask, si int) uint32 {
+	index := uint32(1)
+	for i := 0; i < si; i++ {
+		if len(subtasks[i].Tests) == 0 || (i == 0 && subtasks[i].Score == 0) {
+			continue
+		}
+		index++
+	}
+
+	if si == 0 && subtasks[0].Score == 0 {
+		return 0
+	}
+
+	return index
+}
+
+// containsAll reports whether every item of subset is in set
+func containsAll[T comparable](set, subset []T) bool {
+	items := map[T]bool{}
+	for _, v := range set {
+		items[v] = true
+	}
+	for _, v := range subset {
+		if !items[v] {
+			return false
+		}
+	}
+	return true
+}
+
+func (imp CmsImporter) GetTemplates(pid *string) ([]*atlas.Template, error) {
+	return nil, nil
+}
+
+// GetAttachments uploads files from att/ which are given to contestants
+func (imp CmsImporter) GetAttachments(pid *string) ([]*atlas.Attachment, error) {
+	return UploadAttachments(imp.context, imp.ts, filepath.Join(imp.path, "att"), *pid)
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"fmt"
+)
+
+// cmsCheckerAdapter wraps source of CMS checker, so it can be run as testlib checker.
+//
+// CMS checkers are called as "checker input answer output", print the score from 0.0 to 1.0 to stdout and the
+// message to stderr. Eolymp calls checkers as "checker input output answer" and expects testlib exit codes. The
+// adapter renames main of the checker, swaps the arguments and reads the score printed by it: the full score is
+// accepted, any other score is a wrong answer, so partial scores of a test are lost.
+func cmsCheckerAdapter(source string) string {
+	return fmt.Sprintf(cmsAdapterTemplate, source)
+}
+
+const cmsAdapterTemplate = `#include <bits/stdc++.h>
+#include <unistd.h>
+
+static std::string cms_score_file;
+
+// cms_finish maps the score printed by the checker to testlib exit code
+static int cms_finish(int code) {
+	std::fflush(stdout);
+
+	double score = -1;
+	std::ifstream printed(cms_score_file);
+	printed >> score;
+	printed.close();
+	std::remove(cms_score_file.c_str());
+
+	if (code != 0 || score < 0) {
+		std::cerr << "checker failed with exit code " << code << std::endl;
+		return 3;
+	}
+
+	return score >= 1 - 1e-9 ? 0 : 1;
+}
+
+[[noreturn]] static void cms_exit(int code) {
+	std::exit(cms_finish(code));
+}
+
+namespace std {
+	[[noreturn]] static void cms_exit(int code) {
+		::cms_exit(code);
+	}
+}
+
+#define exit cms_exit
+#define main cms_checker_main
+
+%s
+
+#undef main
+#undef exit
+
+int main(int argc, char* argv[]) {
+	if (argc < 4) {
+		std::cerr << "usage: " << argv[0] << " input output answer" << std::endl;
+		return 3;
+	}
+
+	char file[] = "/tmp/cms-score-XXXXXX";
+	int fd = mkstemp(file);
+	if (fd < 0) {
+		std::cerr << "unable to create score file" << std::endl;
+		return 3;
+	}
+
+	close(fd);
+	cms_score_file = file;
+
+	if (!std::freopen(file, "w", stdout)) {
+		std::cerr << "unable to open score file" << std::endl;
+		return 3;
+	}
+
+	std::vector<std::string> args = {argv[0], argv[1], argv[3], argv[2]};
+	std::vector<char*> ptrs;
+	for (auto& arg : args) {
+		ptrs.push_back(&arg[0]);
+	}
+	ptrs.push_back(nullptr);
+
+	return cms_finish(cms_checker_main((int)args.size(), ptrs.data()));
+}
+`
@@ -0,0 +1,246 @@
+package types_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"github.com/eolymp/polyglot/cmd/runner"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCmsSubtasks(t *testing.T) {
+	tt := []struct {
+		name  string
+		files map[string]string
+		want  []types.CmsSubtask
+	}{
+		{
+			name:  "sum",
+			files: map[string]string{"task.yaml": "score_type: Sum\nscore_type_parameters: 25\n"},
+			want:  []types.CmsSubtask{{Score: 100, Tests: []int{0, 1, 2, 3}}},
+		},
+		{
+			name:  "group counts",
+			files: map[string]string{"task.yaml": "score_type: GroupMin\nscore_type_parameters: [[0, 1], [40, 1], [60, 2]]\n"},
+			want:  []types.CmsSubtask{{Score: 0, Tests: []int{0}}, {Score: 40, Tests: []int{1}}, {Score: 60, Tests: []int{2, 3}}},
+		},
+		{
+			name:  "group regexps",
+			files: map[string]string{"task.yaml": "score_type: GroupMin\nscore_type_parameters: [[30, \"00[01]\"], [70, \".*\"]]\n"},
+			want:  []types.CmsSubtask{{Score: 30, Tests: []int{0, 1}}, {Score: 70, Tests: []int{0, 1, 2, 3}}},
+		},
+		{
+			name: "gen comments",
+			files: map[string]string{
+				"task.yaml": "score_type: GroupMin\nscore_type_parameters: 25\n",
+				"gen/GEN":   "# ST: 0\n1\n# ST: 50\n2\n3\n# ST: 50\n4\n",
+			},
+			want: []types.CmsSubtask{{Score: 0, Tests: []int{0}}, {Score: 50, Tests: []int{1, 2}}, {Score: 50, Tests: []int{3}}},
+		},
+		{
+			name:  "no parameters",
+			files: map[string]string{"task.yaml": "name: sum\n"},
+			want:  []types.CmsSubtask{{Score: 100, Tests: []int{0, 1, 2, 3}}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+
+			imp, err := types.CreateCmsImporter(dir, context.Background(), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := imp.Subtasks(4)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Subtasks do not match:\nwant %+v\ngot  %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCmsSubtasksWithMissingTests(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"group counts": {"task.yaml": "score_type: GroupMin\nscore_type_parameters: [[40, 2], [60, 3]]\n"},
+		"gen comments": {"task.yaml": "score_type: GroupMin\n", "gen/GEN": "# ST: 40\n1\n2\n# ST: 60\n3\n4\n5\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+
+			imp, err := types.CreateCmsImporter(dir, context.Background(), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := imp.Subtasks(4); err == nil || !strings.Contains(err.Error(), "only 4 tests") {
+				t.Errorf("Expected error about missing tests, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCmsScoreType(t *testing.T) {
+	tt := []struct {
+		scoreType string
+		mode      atlas.ScoringMode
+		score     float32
+	}{
+		{scoreType: "", mode: atlas.ScoringMode_EACH, score: 50},
+		{scoreType: "Sum", mode: atlas.ScoringMode_EACH, score: 50},
+		{scoreType: "GroupMin", mode: atlas.ScoringMode_WORST, score: 100},
+		{scoreType: "GroupMul", mode: atlas.ScoringMode_ALL, score: 50},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.scoreType, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{"task.yaml": fmt.Sprintf("score_type: %v\nscore_type_parameters: [[100, 2]]\n", tc.scoreType)}
+			for i := 0; i < 2; i++ {
+				files[fmt.Sprintf("input/input%d.txt", i)] = fmt.Sprint(i)
+				files[fmt.Sprintf("output/output%d.txt", i)] = fmt.Sprint(i)
+			}
+			writeFiles(t, dir, files)
+
+			imp, err := types.CreateCmsImporter(dir, context.Background(), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			groups, err := imp.GetTestsets()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(groups) != 1 || len(groups[0].Tests) != 2 {
+				t.Fatalf("Expected a testset with 2 tests, got %v", groups)
+			}
+
+			if mode := groups[0].Testset.GetScoringMode(); mode != tc.mode {
+				t.Errorf("Scoring mode is %v, expected %v", mode, tc.mode)
+			}
+
+			if score := groups[0].Tests[0].GetScore(); score != tc.score {
+				t.Errorf("Test score is %v, expected %v", score, tc.score)
+			}
+		})
+	}
+}
+
+func TestCmsGeneratedTests(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"task.yaml": "name: sum\n", "gen/GEN": "1\n2\n"})
+
+	imp, err := types.CreateCmsImporter(dir, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := imp.GetTestsets(); err == nil || !strings.Contains(err.Error(), "gen/GEN") {
+		t.Errorf("Expected error about tests of gen/GEN, got %v", err)
+	}
+}
+
+func TestCmsManager(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"task.yaml": "name: sum\n", "check/manager.cpp": "int main() {}\n"})
+
+	imp, err := types.CreateCmsImporter(dir, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !imp.HasInteractor() {
+		t.Fatal("Task with a manager must have an interactor")
+	}
+
+	if _, err := imp.GetInteractor(); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Manager must be rejected, got %v", err)
+	}
+}
+
+const cmsChecker = `#include <cstdio>
+#include <fstream>
+#include <iostream>
+#include <string>
+
+int main(int argc, char** argv) {
+	std::ifstream answer(argv[2]), output(argv[3]);
+	std::string expected, found;
+	answer >> expected;
+	output >> found;
+
+	if (found != expected) {
+		printf("0.0\n");
+		std::cerr << "translate:wrong" << std::endl;
+		return 0;
+	}
+
+	std::cout << "1.0" << std::endl;
+	std::cerr << "translate:success" << std::endl;
+	return 0;
+}
+`
+
+func TestCmsCheckerAdapter(t *testing.T) {
+	if _, err := exec.LookPath(runner.DefaultCompiler); err != nil {
+		t.Skip("C++ compiler is not available")
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"task.yaml":         "name: sum\n",
+		"check/checker.cpp": cmsChecker,
+		"input":             "1 2\n",
+		"answer":            "3\n",
+		"correct":           "3\n",
+		"wrong":             "4\n",
+	})
+
+	imp, err := types.CreateCmsImporter(dir, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := imp.GetVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := filepath.Join(dir, "adapter.cpp")
+	if err := os.WriteFile(source, []byte(verifier.GetSource()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	binary := filepath.Join(dir, "adapter")
+	if err := runner.NewCompiler("", nil).Compile(context.Background(), source, binary); err != nil {
+		t.Fatal(err)
+	}
+
+	for output, code := range map[string]int{"correct": 0, "wrong": 1} {
+		// testlib checkers are called as "checker input output answer"
+		args := []string{filepath.Join(dir, "input"), filepath.Join(dir, output), filepath.Join(dir, "answer")}
+
+		result, err := runner.Run(context.Background(), binary, args, nil, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.ExitCode != code {
+			t.Errorf("Checker exits with %v on %v output, expected %v: %v", result.ExitCode, output, code, result.Message())
+		}
+	}
+}
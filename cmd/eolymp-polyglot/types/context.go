@@ -0,0 +1,21 @@
+package types
+
+import "context"
+
+type contextKey int
+
+const contextJobs contextKey = iota
+
+// JobsFromContext returns number of concurrent workers used for uploads, at least one
+func JobsFromContext(ctx context.Context) int {
+	jobs, ok := ctx.Value(contextJobs).(int)
+	if !ok || jobs < 1 {
+		return 1
+	}
+
+	return jobs
+}
+
+func ContextWithJobs(ctx context.Context, jobs int) context.Context {
+	return context.WithValue(ctx, contextJobs, jobs)
+}
@@ -0,0 +1,186 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/ecm"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"gopkg.in/ini.v1"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DomjudgeImporter imports DOMjudge problem packages. The package layout follows Kattis problem package format, with
+// domjudge-problem.ini on top of it and problem.yaml being optional.
+type DomjudgeImporter struct {
+	KattisImporter
+	ini *DomjudgeSpecification
+}
+
+// DomjudgeSpecification is domjudge-problem.ini of the package
+type DomjudgeSpecification struct {
+	ProbId         string  `ini:"probid"`
+	Name           string  `ini:"name"`
+	TimeLimit      float64 `ini:"timelimit"`
+	Color          string  `ini:"color"`
+	SpecialCompare string  `ini:"special_compare"`
+	SpecialRun     string  `ini:"special_run"`
+	Points         float64 `ini:"points"`
+}
+
+func CreateDomjudgeImporter(path string, context context.Context, ts *typewriter.TypewriterService, kpr *keeper.KeeperService) (*DomjudgeImporter, error) {
+	importer := new(DomjudgeImporter)
+	importer.path = path
+	importer.context = context
+	importer.ts = ts
+	importer.kpr = kpr
+	importer.ini = &DomjudgeSpecification{}
+	importer.spec = &KattisSpecification{}
+
+	if _, err := os.Stat(filepath.Join(path, "problem.yaml")); err == nil {
+		spec, err := ReadKattisSpecification(path)
+		if err != nil {
+			return nil, err
+		}
+		importer.spec = spec
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "domjudge-problem.ini")); err == nil {
+		cfg, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true}, filepath.Join(path, "domjudge-problem.ini"))
+		if err != nil {
+			log.Printf("Unable to parse domjudge-problem.ini: %v", err)
+			return nil, err
+		}
+
+		if err := cfg.Section("").MapTo(importer.ini); err != nil {
+			log.Printf("Unable to parse domjudge-problem.ini: %v", err)
+			return nil, err
+		}
+	}
+
+	if importer.ini.Name != "" && importer.name(KattisDefaultLocale) == "" {
+		importer.spec.Name = importer.ini.Name
+	}
+
+	importer.timeLimit = importer.ini.TimeLimit
+
+	if importer.ini.Color != "" {
+		log.Printf("Problem color %v is not imported", importer.ini.Color)
+	}
+
+	if importer.ini.SpecialCompare != "" || importer.ini.SpecialRun != "" {
+		log.Printf("Executables %#v and %#v are not part of the package, output_validators/ is used instead", importer.ini.SpecialCompare, importer.ini.SpecialRun)
+	}
+
+	for verdict, files := range importer.Submissions() {
+		log.Printf("Found %v %v submissions", len(files), verdict)
+	}
+
+	return importer, nil
+}
+
+// GetStatements returns LaTeX statements if the package has them, otherwise the statement refers to problem.pdf,
+// which is uploaded as attachment
+func (imp DomjudgeImporter) GetStatements(source string) ([]*atlas.Statement, error) {
+	statements, err := imp.KattisImporter.GetStatements(source)
+	if err != nil || len(statements) > 0 {
+		return statements, err
+	}
+
+	attachment, err := imp.pdfAttachment()
+	if err != nil || attachment == nil {
+		return nil, err
+	}
+
+	if source == "" {
+		source = imp.spec.Source
+	}
+
+	content := fmt.Sprintf("The statement is attached as \\href{%v}{%v}.", attachment.Link, attachment.Name)
+
+	return []*atlas.Statement{{
+		Locale:  KattisDefaultLocale,
+		Title:   imp.name(KattisDefaultLocale),
+		Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: content}},
+		Author:  imp.spec.Author,
+		Source:  source,
+	}}, nil
+}
+
+// GetAttachments returns files from attachments/, and problem.pdf if the package has no LaTeX statement
+func (imp DomjudgeImporter) GetAttachments(pid *string) ([]*atlas.Attachment, error) {
+	attachments, err := imp.KattisImporter.GetAttachments(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := imp.KattisImporter.GetStatements("")
+	if err != nil || len(statements) > 0 {
+		return attachments, err
+	}
+
+	attachment, err := imp.pdfAttachment()
+	if err != nil || attachment == nil {
+		return attachments, err
+	}
+
+	attachment.ProblemId = *pid
+
+	return append(attachments, attachment), nil
+}
+
+// pdfAttachment returns problem.pdf as attachment, nil is returned if the package does not have it. The file is
+// uploaded once even though both the statement and the attachment refer to it.
+func (imp DomjudgeImporter) pdfAttachment() (*atlas.Attachment, error) {
+	for _, name := range []string{"problem.pdf", "problem_statement/problem.pdf", "statement/problem.pdf"} {
+		data, err := ioutil.ReadFile(filepath.Join(imp.path, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &atlas.Attachment{Name: filepath.Base(name), Link: UploadAsset(imp.ts, filepath.Base(name), data)}, nil
+	}
+
+	return nil, nil
+}
+
+// Submissions returns files from submissions/ grouped by the expected verdict directory (accepted, wrong_answer and
+// so on)
+func (imp DomjudgeImporter) Submissions() map[string][]string {
+	result := map[string][]string{}
+
+	verdicts, err := ioutil.ReadDir(filepath.Join(imp.path, "submissions"))
+	if err != nil {
+		return result
+	}
+
+	for _, verdict := range verdicts {
+		if !verdict.IsDir() {
+			continue
+		}
+
+		files, err := ioutil.ReadDir(filepath.Join(imp.path, "submissions", verdict.Name()))
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			if _, ok := LanguageByExtension(file.Name()); ok && !file.IsDir() {
+				result[verdict.Name()] = append(result[verdict.Name()], filepath.Join(imp.path, "submissions", verdict.Name(), file.Name()))
+			}
+		}
+
+		sort.Strings(result[verdict.Name()])
+	}
+
+	return result
+}
@@ -0,0 +1,45 @@
+package types_test
+
+import (
+	"context"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"strings"
+	"testing"
+)
+
+func TestDomjudgePdfStatement(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"domjudge-problem.ini": "name='Sum'\ntimelimit='2'\n",
+		"problem.pdf":          "%PDF-1.4",
+	})
+
+	imp, err := types.CreateDomjudgeImporter(dir, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements, err := imp.GetStatements("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid := "problem"
+	attachments, err := imp.GetAttachments(&pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statements) != 1 || len(attachments) != 1 {
+		t.Fatalf("Expected a statement and an attachment, got %v and %v", len(statements), len(attachments))
+	}
+
+	attachment := attachments[0]
+	if attachment.GetName() != "problem.pdf" || attachment.GetProblemId() != pid {
+		t.Errorf("Attachment is not correct: %v", attachment)
+	}
+
+	if statement := statements[0]; statement.GetTitle() != "Sum" || !strings.Contains(statement.GetContent().GetLatex(), attachment.GetLink()) {
+		t.Errorf("Statement must refer to the attachment: %v", statement)
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/eolymp/go-sdk/eolymp/atlas"
 	"github.com/eolymp/go-sdk/eolymp/ecm"
 	"github.com/eolymp/go-sdk/eolymp/executor"
@@ -12,7 +13,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 )
 
@@ -20,6 +20,7 @@ type DotsImporter struct {
 	Importer
 	path          string
 	mainStatement string
+	statements    []string
 	context       context.Context
 	ts            *typewriter.TypewriterService
 	kpr           *keeper.KeeperService
@@ -38,50 +39,99 @@ func CreateDotsImporter(path string, context context.Context, ts *typewriter.Typ
 		return nil, err
 	}
 	for _, statement := range files {
-		if filepath.Ext(statement.Name()) == ".tex" {
-			if strings.Contains(statement.Name(), "ua") && !strings.Contains(statement.Name(), "tutorial") {
-				importer.mainStatement = statement.Name()
-			}
+		if filepath.Ext(statement.Name()) != ".tex" || strings.Contains(statement.Name(), "tutorial") {
+			continue
+		}
+
+		importer.statements = append(importer.statements, statement.Name())
+
+		// limits and examples are taken from the Ukrainian statement if there is one
+		if importer.mainStatement == "" || LocaleFromFileName(statement.Name(), "") == "uk" {
+			importer.mainStatement = statement.Name()
 		}
 	}
+
+	if importer.mainStatement == "" {
+		return nil, errors.New("statement is not found in files/")
+	}
+
 	return importer, nil
 }
 
 func (imp DotsImporter) GetVerifier() (*executor.Verifier, error) {
-	// todo
+	lang, source, ok, err := readProgram(filepath.Join(imp.path, "files"), "check")
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return &executor.Verifier{Type: executor.Verifier_PROGRAM, Source: source, Lang: lang}, nil
+	}
+
 	return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: true}, nil
 }
 
 func (imp DotsImporter) HasInteractor() bool {
-	return false
+	_, _, ok, _ := readProgram(filepath.Join(imp.path, "files"), "interactor", "inter")
+	return ok
 }
 
 func (imp DotsImporter) GetInteractor() (*executor.Interactor, error) {
-	return nil, nil
+	lang, source, ok, err := readProgram(filepath.Join(imp.path, "files"), "interactor", "inter")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return &executor.Interactor{Type: executor.Interactor_PROGRAM, Source: source, Lang: lang}, nil
 }
 
+// GetStatements imports every statement from files/, the locale is taken from the file name (ua, ru, en)
 func (imp DotsImporter) GetStatements(source string) ([]*atlas.Statement, error) {
-	data, err := ioutil.ReadFile(filepath.Join(imp.path, "files", imp.mainStatement))
-	if err != nil {
-		return nil, err
-	}
-	d := string(data)
-	name := strings.Split(d, "\n")[1][2:]
-	statement := strings.Join(strings.Split(d, "\n")[2:], "\n")
-	statement = statement[0:strings.Index(statement, "\\Example")]
 	var statements []*atlas.Statement
-	statements = append(statements, &atlas.Statement{
-		Locale:  "uk",
-		Title:   name,
-		Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: statement}},
-		Author:  "",
-		Source:  source,
-	})
+	for _, name := range imp.statements {
+		data, err := ioutil.ReadFile(filepath.Join(imp.path, "files", name))
+		if err != nil {
+			return nil, err
+		}
+
+		title, statement := dotsStatement(string(data))
+		statements = append(statements, &atlas.Statement{
+			Locale:  LocaleFromFileName(name, "uk"),
+			Title:   title,
+			Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: statement}},
+			Author:  "",
+			Source:  source,
+		})
+	}
 	return statements, nil
 }
 
+// dotsStatement returns title and text of the statement. Statements start with a line and "% title" comment, the
+// title is taken from the olymp.sty header if the comment is missing. Examples are imported as tests.
+func dotsStatement(data string) (string, string) {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	title := ""
+	if len(lines) > 1 && strings.HasPrefix(lines[1], "% ") {
+		title = strings.TrimSpace(lines[1][2:])
+	} else if header := OlympHeader(data); len(header) > 0 {
+		title = header[0]
+	}
+
+	statement := ""
+	if len(lines) > 2 {
+		statement = strings.Join(lines[2:], "\n")
+	}
+
+	if i := strings.Index(statement, "\\Example"); i >= 0 {
+		statement = statement[0:i]
+	}
+
+	return title, statement
+}
+
 func (imp DotsImporter) GetSolutions() ([]*atlas.Editorial, error) {
-	return nil, nil
+	return GetTutorialsFromLocation(imp.context, imp.ts, filepath.Join(imp.path, "files"))
 }
 
 func (imp DotsImporter) GetTestsets() ([]*Group, error) {
@@ -92,11 +142,23 @@ func (imp DotsImporter) GetTestsets() ([]*Group, error) {
 		return nil, err
 	}
 	data := string(stf)
-	split := strings.Split(data, "{")
-	seconds, _ := strconv.ParseFloat(strings.Split(split[5], " ")[0], 32)
-	time := uint32(seconds * 1000)
-	megabytes, _ := strconv.ParseFloat(strings.Split(split[6], " ")[0], 32)
-	memory := uint64(megabytes * 1024 * 1024)
+
+	time, memory := uint32(1000), uint64(256*1024*1024)
+	if header := OlympHeader(data); len(header) == 5 {
+		if tl, ok := ParseTimeLimit(header[3]); ok {
+			time = tl
+		} else {
+			log.Printf("Unable to parse time limit %#v, using 1 second", header[3])
+		}
+
+		if ml, ok := ParseMemoryLimit(header[4]); ok {
+			memory = ml
+		} else {
+			log.Printf("Unable to parse memory limit %#v, using 256 MB", header[4])
+		}
+	} else {
+		log.Printf("Limits are not found in %v, using 1 second and 256 MB", imp.mainStatement)
+	}
 
 	if jsonFile, err := os.Open(filepath.Join(imp.path, "files/problem.config")); err != nil {
 		testset := &atlas.Testset{}
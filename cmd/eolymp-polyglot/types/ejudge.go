@@ -3,6 +3,7 @@ package types
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/eolymp/go-sdk/eolymp/atlas"
 	"github.com/eolymp/go-sdk/eolymp/ecm"
 	"github.com/eolymp/go-sdk/eolymp/executor"
@@ -10,6 +11,8 @@ import (
 	"github.com/eolymp/go-sdk/eolymp/typewriter"
 	"io/ioutil"
 	"log"
+	"math"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -22,11 +25,9 @@ type EjudgeImporter struct {
 	context       context.Context
 	ts            *typewriter.TypewriterService
 	kpr           *keeper.KeeperService
-	config        map[string]string
+	config        *ServeProblem
 }
 
-const EjudgeDefaultLang = "gpp"
-
 func CreateEjudgeImporter(path string, context context.Context, ts *typewriter.TypewriterService, kpr *keeper.KeeperService) (*EjudgeImporter, error) {
 	importer := new(EjudgeImporter)
 	importer.path = path
@@ -45,103 +46,115 @@ func CreateEjudgeImporter(path string, context context.Context, ts *typewriter.T
 			}
 		}
 	}
-	importer.config, err = CreateConfig(filepath.Join(path, "../../conf/serve.cfg"), path[strings.LastIndex(path, "/")+1:])
+	config, err := ReadServeConfig(filepath.Join(path, "../../conf/serve.cfg"))
 	if err != nil {
 		log.Println("Failed to get config")
 		return nil, err
 	}
+	importer.config, err = config.Problem(filepath.Base(filepath.Clean(path)))
+	if err != nil {
+		return nil, err
+	}
 	return importer, nil
 }
 
-func CreateConfig(path string, letter string) (map[string]string, error) {
-	bytes, err := ioutil.ReadFile(path)
+// ejudgeTokenCheckers are standard checkers which compare tokens exactly
+var ejudgeTokenCheckers = map[string]bool{
+	"cmp_file_nospace":       true,
+	"cmp_int":                true,
+	"cmp_int_seq":            true,
+	"cmp_long_long":          true,
+	"cmp_long_long_seq":      true,
+	"cmp_unsigned_int":       true,
+	"cmp_unsigned_int_seq":   true,
+	"cmp_unsigned_long_long": true,
+	"cmp_huge_int":           true,
+}
+
+// ejudgeDoubleCheckers are standard checkers which compare numbers with EPS given in checker_env
+var ejudgeDoubleCheckers = map[string]bool{
+	"cmp_double":          true,
+	"cmp_double_seq":      true,
+	"cmp_long_double":     true,
+	"cmp_long_double_seq": true,
+}
+
+func (imp EjudgeImporter) GetVerifier() (*executor.Verifier, error) {
+	switch checker := imp.config.StandardChecker(); {
+	case checker == "":
+	case checker == "cmp_file" || checker == "cmp_bytes":
+		return &executor.Verifier{Type: executor.Verifier_LINES}, nil
+	case checker == "cmp_yesno":
+		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: false}, nil
+	case ejudgeTokenCheckers[checker]:
+		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: true}, nil
+	case ejudgeDoubleCheckers[checker]:
+		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: imp.precision(), CaseSensitive: true}, nil
+	default:
+		log.Printf("Standard checker %#v is not supported, looking for checker source", checker)
+	}
+
+	lang, source, ok, err := readProgram(imp.path, imp.programNames("check_cmd", "check", "checker")...)
 	if err != nil {
-		log.Println("Can't read file")
 		return nil, err
 	}
-	data := string(bytes)
-	list := strings.Split(data, "\n\n")
-	var problems []map[string]string
-	for _, obj := range list {
-		rows := strings.Split(obj, "\n")
-		if rows[0] == "[problem]" {
-			m := map[string]string{}
-			for _, row := range rows {
-				s := strings.Split(row, " = ")
-				if len(s) == 1 {
-					m[s[0]] = ""
-				} else {
-					if s[1][0] == '"' && s[1][len(s[1])-1] == '"' {
-						m[s[0]] = s[1][1 : len(s[1])-1]
-					} else {
-						m[s[0]] = s[1]
-					}
-				}
-			}
-			problems = append(problems, m)
-		}
+
+	if ok {
+		return &executor.Verifier{Type: executor.Verifier_PROGRAM, Source: source, Lang: lang}, nil
 	}
-	var problem map[string]string
-	for _, prob := range problems {
-		if prob["short_name"] == letter {
-			problem = prob
+
+	return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: true}, nil
+}
+
+// precision returns number of digits after the decimal point from EPS in checker_env, like "EPS=1e-6"
+func (imp EjudgeImporter) precision() int32 {
+	for _, item := range strings.FieldsFunc(imp.config.String("checker_env", ""), func(r rune) bool {
+		return r == ';' || r == ' '
+	}) {
+		if !strings.HasPrefix(item, "EPS=") {
+			continue
 		}
-	}
-	super, hasSuper := problem["super"]
-	if hasSuper {
-		for _, prob := range problems {
-			if prob["short_name"] == super {
-				for k, v := range prob {
-					_, ok := problem[k]
-					if !ok {
-						problem[k] = v
-					}
-				}
-			}
+
+		eps, err := strconv.ParseFloat(strings.TrimPrefix(item, "EPS="), 64)
+		if err != nil || eps <= 0 {
+			log.Printf("Invalid %#v in checker_env", item)
+			break
 		}
+
+		return int32(math.Round(-math.Log10(eps)))
 	}
-	return problem, nil
+
+	log.Println("EPS is not set in checker_env, using 6 digits")
+	return 6
 }
 
-func (imp EjudgeImporter) GetVerifier() (*executor.Verifier, error) {
-	names := [2]string{"check.cpp", "checker.cpp"}
+// programNames returns names of the program source given by the parameter (check_cmd, interactor_cmd), followed by
+// the conventional names
+func (imp EjudgeImporter) programNames(key string, names ...string) []string {
+	var prefixes []string
+	if cmd := imp.config.String(key, ""); cmd != "" {
+		prefixes = append(prefixes, strings.TrimSuffix(filepath.Base(cmd), filepath.Ext(cmd))+".")
+	}
+
 	for _, name := range names {
-		data, err := ioutil.ReadFile(filepath.Join(imp.path, name))
-		if err == nil {
-			return &executor.Verifier{
-				Type:   executor.Verifier_PROGRAM,
-				Source: string(data), // todo: actually read file
-				Lang:   EjudgeDefaultLang,
-			}, nil
-		}
+		prefixes = append(prefixes, name+".")
 	}
-	return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: true}, nil
+
+	return prefixes
 }
 
 func (imp EjudgeImporter) HasInteractor() bool {
-	names := [2]string{"inter.cpp", "interactor.cpp"}
-	for _, name := range names {
-		_, err := ioutil.ReadFile(filepath.Join(imp.path, name))
-		if err == nil {
-			return true
-		}
-	}
-	return false
+	_, _, ok, _ := readProgram(imp.path, imp.programNames("interactor_cmd", "inter", "interactor")...)
+	return ok
 }
 
 func (imp EjudgeImporter) GetInteractor() (*executor.Interactor, error) {
-	names := [2]string{"inter.cpp", "interactor.cpp"}
-	for _, name := range names {
-		data, err := ioutil.ReadFile(filepath.Join(imp.path, name))
-		if err == nil {
-			return &executor.Interactor{
-				Type:   executor.Interactor_PROGRAM,
-				Source: string(data), // todo: actually read file
-				Lang:   EjudgeDefaultLang,
-			}, nil
-		}
+	lang, source, ok, err := readProgram(imp.path, imp.programNames("interactor_cmd", "inter", "interactor")...)
+	if err != nil || !ok {
+		return nil, err
 	}
-	return nil, nil
+
+	return &executor.Interactor{Type: executor.Interactor_PROGRAM, Source: source, Lang: lang}, nil
 }
 
 func (imp EjudgeImporter) GetStatements(source string) ([]*atlas.Statement, error) {
@@ -154,7 +167,7 @@ func (imp EjudgeImporter) GetStatements(source string) ([]*atlas.Statement, erro
 		statement = statement[0:strings.Index(statement, "\\Example")]
 	} else {
 		statement = ""
-		name = imp.config["long_name"]
+		name = imp.config.LongName()
 	}
 
 	var statements []*atlas.Statement
@@ -169,7 +182,7 @@ func (imp EjudgeImporter) GetStatements(source string) ([]*atlas.Statement, erro
 }
 
 func (imp EjudgeImporter) GetSolutions() ([]*atlas.Editorial, error) {
-	return nil, nil
+	return GetTutorialsFromLocation(imp.context, imp.ts, filepath.Join(imp.path, "statement"))
 }
 
 func (imp EjudgeImporter) GetTestsets() ([]*Group, error) {
@@ -191,29 +204,44 @@ func (imp EjudgeImporter) GetTestsets() ([]*Group, error) {
 	samples.Testset = testset
 	samples.Name = 0
 
-	valuerCmd, hasValuer := imp.config["valuer_cmd"]
+	valuerCmd, hasValuer := imp.config.Get("valuer_cmd")
+
+	scores, err := imp.config.TestScoreList()
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := imp.memoryLimit()
+	if err != nil {
+		return nil, err
+	}
 
 	stf, err := ioutil.ReadFile(filepath.Join(imp.path, "statement", imp.mainStatement))
 	if err != nil {
-		tl, _ := strconv.Atoi(imp.config["time_limit_millis"])
+		tl, err := imp.config.TimeLimit()
+		if err != nil {
+			return nil, err
+		}
 		if tl < 1000 {
 			tl = 1000
 		}
-		time = uint32(tl)
-		memory = 536870912 // TODO
+		time = tl
+		memory = 536870912
+		if limit > 0 {
+			memory = limit
+		}
 		testset.TimeLimit = time
 		testset.MemoryLimit = memory
-		scores, ok := imp.config["test_score_list"]
-		if ok && !hasValuer {
+		if scores != nil && !hasValuer {
 			sampleTests := 0
-			for _, item := range strings.Split(scores, " ") {
-				if item == "0" {
+			for _, score := range scores {
+				if score == 0 {
 					sampleTests++
 				} else {
 					break
 				}
 			}
-			tests, err := GetTestsFromLocation(filepath.Join(imp.path, "tests"), imp.kpr)
+			tests, err := imp.uploadTests()
 			if err != nil {
 				return nil, err
 			}
@@ -234,6 +262,9 @@ func (imp EjudgeImporter) GetTestsets() ([]*Group, error) {
 		time = uint32(seconds * 1000)
 		megabytes, _ := strconv.Atoi(strings.Split(split[8], " ")[0])
 		memory = uint64(megabytes * 1024 * 1024)
+		if limit > 0 {
+			memory = limit
+		}
 		testset.TimeLimit = time
 		testset.MemoryLimit = memory
 		split = strings.Split(data, "\\exmp{")
@@ -280,7 +311,7 @@ func (imp EjudgeImporter) GetTestsets() ([]*Group, error) {
 	}
 	groups = append(groups, samples)
 
-	tests, err := GetTestsFromLocation(filepath.Join(imp.path, "tests"), imp.kpr)
+	tests, err := imp.uploadTests()
 	if err != nil {
 		return nil, err
 	}
@@ -374,18 +405,20 @@ func (imp EjudgeImporter) GetTestsets() ([]*Group, error) {
 
 		newGroup := new(Group)
 
-		score_list, scoresSet := imp.config["test_score_list"]
-		scores := strings.Split(score_list, " ")
+		scoresSet := scores != nil
+		fallback, err := imp.config.Int("test_score", 1)
+		if err != nil {
+			return nil, err
+		}
 
 		for ind, test := range tests {
 			test.Example = false
 			test.Score = 1
 			if scoresSet {
-				score, err := strconv.Atoi(scores[ind])
-				if err != nil {
-					return nil, err
+				test.Score = float32(fallback)
+				if ind < len(scores) {
+					test.Score = float32(scores[ind])
 				}
-				test.Score = float32(score)
 				if test.Score == 0 {
 					continue
 				}
@@ -415,6 +448,85 @@ func (imp EjudgeImporter) GetTestsets() ([]*Group, error) {
 	return groups, nil
 }
 
+// memoryLimit returns max_vm_size of serve.cfg, raised to max_stack_size since Eolymp has a single limit for the
+// whole program, 0 means the limit is not set
+func (imp EjudgeImporter) memoryLimit() (uint64, error) {
+	limit, err := imp.config.MaxVmSize()
+	if err != nil {
+		return 0, err
+	}
+
+	stack, err := imp.config.MaxStackSize()
+	if err != nil {
+		return 0, err
+	}
+
+	if limit > 0 && stack > limit {
+		limit = stack
+	}
+
+	return limit, nil
+}
+
+// testDir returns directory given by the parameter (test_dir, corr_dir), either relative to the problem or to
+// tests/ of the contest
+func (imp EjudgeImporter) testDir(key, fallback string) string {
+	dir := imp.config.String(key, "")
+	if dir == "" {
+		return fallback
+	}
+
+	for _, location := range []string{filepath.Join(imp.path, dir), filepath.Join(imp.path, "../../tests", dir)} {
+		if info, err := os.Stat(location); err == nil && info.IsDir() {
+			return location
+		}
+	}
+
+	log.Printf("Directory %#v given by %v is not found", dir, key)
+	return fallback
+}
+
+// testPaths lists tests by test_pat and corr_pat of serve.cfg, answers are required when use_corr is set. If there
+// are no tests matching the patterns, files of tests/ are paired by extension.
+func (imp EjudgeImporter) testPaths() ([]TestPath, error) {
+	dir := imp.testDir("test_dir", filepath.Join(imp.path, "tests"))
+	corr := imp.testDir("corr_dir", dir)
+	useCorr := imp.config.Bool("use_corr")
+
+	var paths []TestPath
+	for i := 1; ; i++ {
+		input := filepath.Join(dir, fmt.Sprintf(imp.config.TestPattern(), i))
+		if _, err := os.Stat(input); err != nil {
+			break
+		}
+
+		path := TestPath{input: input}
+
+		answer := filepath.Join(corr, fmt.Sprintf(imp.config.CorrPattern(), i))
+		if _, err := os.Stat(answer); err == nil || useCorr {
+			path.output = answer
+		}
+
+		paths = append(paths, path)
+	}
+
+	if len(paths) == 0 {
+		log.Printf("No tests match %#v in %v, looking for tests by extension", imp.config.TestPattern(), dir)
+		return GetTestPathsFromLocation(filepath.Join(imp.path, "tests"))
+	}
+
+	return paths, nil
+}
+
+func (imp EjudgeImporter) uploadTests() ([]*atlas.Test, error) {
+	paths, err := imp.testPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	return UploadTestPaths(imp.context, imp.kpr, paths)
+}
+
 func ReadGvaluerConfig(path string) (map[string]map[string]string, error) {
 	bytes, err := ioutil.ReadFile(path)
 	if err != nil {
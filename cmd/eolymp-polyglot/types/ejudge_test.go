@@ -0,0 +1,100 @@
+package types_test
+
+import (
+	"context"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"path/filepath"
+	"testing"
+)
+
+func TestEjudgeVerifier(t *testing.T) {
+	tt := []struct {
+		name          string
+		params        string
+		files         map[string]string
+		kind          executor.Verifier_Type
+		precision     int32
+		caseSensitive bool
+	}{
+		{name: "cmp_file", params: "standard_checker = cmp_file\n", kind: executor.Verifier_LINES},
+		{name: "cmp_bytes", params: "standard_checker = cmp_bytes\n", kind: executor.Verifier_LINES},
+		{name: "cmp_yesno", params: "standard_checker = cmp_yesno\n", kind: executor.Verifier_TOKENS},
+		{name: "cmp_int_seq", params: "standard_checker = cmp_int_seq\n", kind: executor.Verifier_TOKENS, caseSensitive: true},
+		{name: "cmp_file_nospace", params: "standard_checker = cmp_file_nospace\n", kind: executor.Verifier_TOKENS, caseSensitive: true},
+		{
+			name:          "cmp_double",
+			params:        "standard_checker = cmp_double\nchecker_env = \"EPS=1e-4\"\n",
+			kind:          executor.Verifier_TOKENS,
+			precision:     4,
+			caseSensitive: true,
+		},
+		{
+			name:          "cmp_long_double_seq",
+			params:        "standard_checker = cmp_long_double_seq\nchecker_env = \"MAX_LINES=10;EPS=0.001\"\n",
+			kind:          executor.Verifier_TOKENS,
+			precision:     3,
+			caseSensitive: true,
+		},
+		{
+			name:          "cmp_double without eps",
+			params:        "standard_checker = cmp_double\n",
+			kind:          executor.Verifier_TOKENS,
+			precision:     6,
+			caseSensitive: true,
+		},
+		{
+			name:          "cmp_double with invalid eps",
+			params:        "standard_checker = cmp_double\nchecker_env = \"EPS=small\"\n",
+			kind:          executor.Verifier_TOKENS,
+			precision:     6,
+			caseSensitive: true,
+		},
+		{
+			name:   "unsupported with source",
+			params: "standard_checker = cmp_sorted\n",
+			files:  map[string]string{"problems/sum/check.cpp": "int main() {}\n"},
+			kind:   executor.Verifier_PROGRAM,
+		},
+		{
+			name:   "check_cmd",
+			params: "check_cmd = \"checker_sum\"\n",
+			files:  map[string]string{"problems/sum/checker_sum.cpp": "int main() {}\n"},
+			kind:   executor.Verifier_PROGRAM,
+		},
+		{name: "default", kind: executor.Verifier_TOKENS, caseSensitive: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			files := map[string]string{"conf/serve.cfg": "[problem]\nid = 1\nshort_name = A\ninternal_name = sum\n" + tc.params}
+			for name, content := range tc.files {
+				files[name] = content
+			}
+			writeFiles(t, root, files)
+
+			imp, err := types.CreateEjudgeImporter(filepath.Join(root, "problems", "sum"), context.Background(), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verifier, err := imp.GetVerifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if verifier.GetType() != tc.kind {
+				t.Fatalf("Verifier type is %v, expected %v", verifier.GetType(), tc.kind)
+			}
+
+			if verifier.GetPrecision() != tc.precision {
+				t.Errorf("Precision is %v, expected %v", verifier.GetPrecision(), tc.precision)
+			}
+
+			if verifier.GetCaseSensitive() != tc.caseSensitive {
+				t.Errorf("Case sensitivity is %v, expected %v", verifier.GetCaseSensitive(), tc.caseSensitive)
+			}
+		})
+	}
+}
@@ -65,6 +65,7 @@ func (imp EolympImporter) GetSolutions() ([]*atlas.Editorial, error) {
 	}
 	for _, solution := range out.GetItems() {
 		solution.Id = ""
+		solution.ProblemId = ""
 		solutions = append(solutions, solution)
 	}
 	return solutions, nil
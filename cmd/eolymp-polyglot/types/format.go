@@ -0,0 +1,125 @@
+package types
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatAuto     = "auto" // detect format by the files of the package
+	FormatPolygon  = "polygon"
+	FormatEolymp   = "eolymp"
+	FormatEjudge   = "ejudge"
+	FormatDots     = "dots"
+	FormatKattis   = "kattis"
+	FormatDomjudge = "domjudge"
+	FormatCms      = "cms"
+	FormatTps      = "tps"
+	FormatPcms2    = "pcms2"
+)
+
+// Formats lists formats which can be given with --format
+var Formats = []string{FormatAuto, FormatPolygon, FormatEolymp, FormatEjudge, FormatDots, FormatKattis, FormatDomjudge, FormatCms, FormatTps, FormatPcms2}
+
+// IsFormat checks that the format is known
+func IsFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectFormat probes the package directory and returns its format. Eolymp problems are not detected since they are
+// imported by ID rather than from a directory.
+func DetectFormat(path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(path, name))
+		return err == nil
+	}
+
+	// problem.xml is specific enough, other probes would match Polygon packages too (they have files/*.tex)
+	switch root := xmlRoot(filepath.Join(path, "problem.xml")); root {
+	case FormatPolygon, FormatPcms2:
+		return root, nil
+	}
+
+	var candidates []string
+
+	if exists("../../conf/serve.cfg") {
+		candidates = append(candidates, FormatEjudge)
+	}
+
+	if tex, _ := filepath.Glob(filepath.Join(path, "files", "*.tex")); len(tex) > 0 && !exists("problem.xml") {
+		candidates = append(candidates, FormatDots)
+	}
+
+	// DOMjudge package is a Kattis package with domjudge-problem.ini on top of it
+	if exists("domjudge-problem.ini") {
+		candidates = append(candidates, FormatDomjudge)
+	} else if exists("problem.yaml") {
+		candidates = append(candidates, FormatKattis)
+	}
+
+	if exists("task.yaml") {
+		candidates = append(candidates, FormatCms)
+	}
+
+	if exists("problem.json") && exists("subtasks.json") {
+		candidates = append(candidates, FormatTps)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("unable to detect format of %#v, use --format to set it", path)
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", fmt.Errorf("format of %#v is ambiguous, it looks like %v, use --format to choose one", path, strings.Join(candidates, ", "))
+	}
+}
+
+// xmlRoot tells Polygon problem.xml from PCMS2 one, empty string is returned if the file is missing or neither of them
+func xmlRoot(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var root struct {
+		XMLName  xml.Name
+		Revision string    `xml:"revision,attr"`
+		Url      string    `xml:"url,attr"`
+		Names    *struct{} `xml:"names"`
+		Judging  *struct {
+			Script  *struct{} `xml:"script"`
+			Testset *struct{} `xml:"testset"`
+		} `xml:"judging"`
+	}
+
+	if err := xml.NewDecoder(file).Decode(&root); err != nil || root.XMLName.Local != "problem" {
+		return ""
+	}
+
+	if root.Revision != "" || root.Url != "" || root.Names != nil {
+		return FormatPolygon
+	}
+
+	if root.Judging != nil && (root.Judging.Script != nil || root.Judging.Testset != nil) {
+		return FormatPcms2
+	}
+
+	return ""
+}
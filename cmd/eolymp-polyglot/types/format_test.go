@@ -0,0 +1,101 @@
+package types_test
+
+import (
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tt := []struct {
+		name   string
+		files  map[string]string
+		dir    string // package directory relative to the root
+		format string // empty means an error is expected
+	}{
+		{
+			name: "polygon",
+			files: map[string]string{
+				"problem.xml":              `<?xml version="1.0" encoding="utf-8"?><problem revision="3" short-name="sum" url="https://polygon.codeforces.com/p/sum"><names><name language="english" value="Sum"/></names></problem>`,
+				"files/olymp.sty":          "",
+				"files/problem.tex":        "",
+				"statements/english/a.tex": "",
+			},
+			format: types.FormatPolygon,
+		},
+		{
+			name: "pcms2",
+			files: map[string]string{
+				"problem.xml":      `<problem id="sum" version="1.0"><judging><script type="%icpc"><testset input-href="tests/##" answer-href="tests/##.a"/></script></judging></problem>`,
+				"files/header.tex": "",
+			},
+			format: types.FormatPcms2,
+		},
+		{
+			name:   "dots",
+			files:  map[string]string{"files/ua.tex": "\\begin{problem}{Sum}{}{}{1}{256}"},
+			format: types.FormatDots,
+		},
+		{
+			name:  "dots with unknown problem.xml",
+			files: map[string]string{"files/ua.tex": "", "problem.xml": "<problem/>"},
+		},
+		{
+			name:   "kattis",
+			files:  map[string]string{"problem.yaml": "name: Sum\n"},
+			format: types.FormatKattis,
+		},
+		{
+			name:   "domjudge",
+			files:  map[string]string{"problem.yaml": "name: Sum\n", "domjudge-problem.ini": "name='Sum'\n"},
+			format: types.FormatDomjudge,
+		},
+		{
+			name:   "cms",
+			files:  map[string]string{"task.yaml": "name: sum\n"},
+			format: types.FormatCms,
+		},
+		{
+			name:   "tps",
+			files:  map[string]string{"problem.json": "{}", "subtasks.json": "{}"},
+			format: types.FormatTps,
+		},
+		{
+			name:   "ejudge",
+			files:  map[string]string{"conf/serve.cfg": "[problem]\n", "problems/sum/tests/001.dat": ""},
+			dir:    "problems/sum",
+			format: types.FormatEjudge,
+		},
+		{
+			name:  "ambiguous",
+			files: map[string]string{"task.yaml": "name: sum\n", "problem.yaml": "name: Sum\n"},
+		},
+		{
+			name:  "unknown",
+			files: map[string]string{"readme.txt": ""},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, tc.files)
+
+			format, err := types.DetectFormat(filepath.Join(root, tc.dir))
+			if tc.format == "" {
+				if err == nil {
+					t.Errorf("Expected an error, got format %#v", format)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if format != tc.format {
+				t.Errorf("Format is %#v, expected %#v", format, tc.format)
+			}
+		})
+	}
+}
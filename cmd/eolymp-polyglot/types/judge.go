@@ -0,0 +1,296 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/polyglot/cmd/runner"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Verdict string
+
+const (
+	VerdictAccepted          Verdict = "OK"
+	VerdictWrongAnswer       Verdict = "WA"
+	VerdictPresentationError Verdict = "PE"
+	VerdictTimeLimit         Verdict = "TL"
+	VerdictMemoryLimit       Verdict = "ML"
+	VerdictRuntimeError      Verdict = "RE"
+	VerdictCheckerFailed     Verdict = "FL"
+	VerdictCompilationError  Verdict = "CE"
+)
+
+// LocalTest is a test of the package on the disk with limits of its testset
+type LocalTest struct {
+	Testset     string
+	Index       int
+	Input       string
+	Answer      string
+	TimeLimit   time.Duration
+	MemoryLimit uint64
+}
+
+// LocalSolution is a reference solution of the package with its tag (main, accepted, wrong-answer and so on)
+type LocalSolution struct {
+	Path string
+	Tag  string
+}
+
+// LocalProgram is a compiled solution which can be run locally
+type LocalProgram struct {
+	Command string
+	Args    []string
+}
+
+// LocalResult is the outcome of a solution on a single test
+type LocalResult struct {
+	Verdict  Verdict
+	Message  string
+	Duration time.Duration
+	Memory   uint64
+}
+
+// LocalJudge compiles solutions and checks them on tests the same way Eolymp would, using the verifier of the problem
+type LocalJudge struct {
+	compiler *runner.Compiler
+	verifier *executor.Verifier
+	dir      string
+	includes []string
+	checker  string
+}
+
+// NewLocalJudge prepares the verifier, binaries are placed into dir and includes are passed to the compiler
+func NewLocalJudge(ctx context.Context, compiler *runner.Compiler, verifier *executor.Verifier, dir string, includes ...string) (*LocalJudge, error) {
+	judge := &LocalJudge{compiler: compiler, verifier: verifier, dir: dir, includes: includes}
+
+	switch verifier.GetType() {
+	case executor.Verifier_TOKENS, executor.Verifier_LINES:
+		return judge, nil
+	case executor.Verifier_PROGRAM:
+		if !strings.HasPrefix(verifier.GetLang(), "cpp") {
+			return nil, fmt.Errorf("checker in %v can not be run locally", verifier.GetLang())
+		}
+
+		source := filepath.Join(dir, "checker.cpp")
+		if err := ioutil.WriteFile(source, []byte(verifier.GetSource()), 0644); err != nil {
+			return nil, err
+		}
+
+		judge.checker = filepath.Join(dir, "checker")
+		if err := compiler.Compile(ctx, source, judge.checker, includes...); err != nil {
+			return nil, err
+		}
+
+		return judge, nil
+	default:
+		return nil, fmt.Errorf("verifier %v can not be run locally", verifier.GetType())
+	}
+}
+
+// Prepare compiles C++ solutions, Python solutions are run with python3
+func (j *LocalJudge) Prepare(ctx context.Context, source string) (*LocalProgram, error) {
+	return PrepareProgram(ctx, j.compiler, j.dir, source, j.includes...)
+}
+
+// PrepareProgram compiles C++ source into a new directory inside dir, Python sources are run with python3
+func PrepareProgram(ctx context.Context, compiler *runner.Compiler, dir, source string, includes ...string) (*LocalProgram, error) {
+	lang, ok := LanguageByExtension(source)
+	if !ok {
+		return nil, fmt.Errorf("language of %v is unknown", filepath.Base(source))
+	}
+
+	switch {
+	case strings.HasPrefix(lang, "cpp"):
+		name := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+
+		binary, err := ioutil.TempDir(dir, name+"-")
+		if err != nil {
+			return nil, err
+		}
+
+		binary = filepath.Join(binary, name)
+		if err := compiler.Compile(ctx, source, binary, includes...); err != nil {
+			return nil, err
+		}
+
+		return &LocalProgram{Command: binary}, nil
+	case lang == "python":
+		return &LocalProgram{Command: "python3", Args: []string{source}}, nil
+	default:
+		return nil, fmt.Errorf("%v programs can not be run locally", lang)
+	}
+}
+
+// Judge runs the program on the test and checks its output. The program is killed when it runs twice as long as the
+// time limit, and its address space is limited to twice the memory limit, so allocations far above the limit fail
+// instead of exhausting the machine. The verdicts are given by the measured time and peak resident memory.
+func (j *LocalJudge) Judge(ctx context.Context, program *LocalProgram, test LocalTest) (*LocalResult, error) {
+	input, err := os.Open(test.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = input.Close()
+	}()
+
+	memory := uint64(0)
+	if test.MemoryLimit > 0 {
+		memory = 2*test.MemoryLimit + 64<<20
+	}
+
+	run, err := runner.Run(ctx, program.Command, program.Args, input, 2*test.TimeLimit+time.Second, memory)
+	if errors.Is(err, runner.ErrTimeLimit) {
+		return &LocalResult{Verdict: VerdictTimeLimit, Duration: run.Duration, Memory: run.Memory}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LocalResult{Duration: run.Duration, Memory: run.Memory}
+
+	switch {
+	case test.TimeLimit > 0 && run.Duration > test.TimeLimit:
+		result.Verdict = VerdictTimeLimit
+	case test.MemoryLimit > 0 && run.Memory > test.MemoryLimit:
+		result.Verdict = VerdictMemoryLimit
+	case run.ExitCode != 0:
+		result.Verdict = VerdictRuntimeError
+		result.Message = fmt.Sprintf("exit code %v", run.ExitCode)
+	default:
+		result.Verdict, result.Message, err = j.check(ctx, test, run.Stdout)
+	}
+
+	return result, err
+}
+
+// check compares output with the answer using the verifier
+func (j *LocalJudge) check(ctx context.Context, test LocalTest, output []byte) (Verdict, string, error) {
+	answer, err := ioutil.ReadFile(test.Answer)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch j.verifier.GetType() {
+	case executor.Verifier_TOKENS:
+		verdict, message := CompareTokens(output, answer, int(j.verifier.GetPrecision()), j.verifier.GetCaseSensitive())
+		return verdict, message, nil
+	case executor.Verifier_LINES:
+		verdict, message := CompareLines(output, answer)
+		return verdict, message, nil
+	}
+
+	file, err := ioutil.TempFile(j.dir, "output-")
+	if err != nil {
+		return "", "", err
+	}
+
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	_, err = file.Write(output)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return "", "", err
+	}
+
+	// testlib checkers are called as "checker input output answer"
+	run, err := runner.Run(ctx, j.checker, []string{test.Input, file.Name(), test.Answer}, nil, 0, 0)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch run.ExitCode {
+	case 0:
+		return VerdictAccepted, run.Message(), nil
+	case 1:
+		return VerdictWrongAnswer, run.Message(), nil
+	case 2:
+		return VerdictPresentationError, run.Message(), nil
+	default:
+		return VerdictCheckerFailed, run.Message(), nil
+	}
+}
+
+// CompareTokens compares whitespace separated tokens, numbers are compared with absolute or relative error of
+// 10^-precision if precision is set
+func CompareTokens(output, answer []byte, precision int, caseSensitive bool) (Verdict, string) {
+	found, expected := strings.Fields(string(output)), strings.Fields(string(answer))
+
+	for i := 0; i < len(found) && i < len(expected); i++ {
+		if found[i] == expected[i] || (!caseSensitive && strings.EqualFold(found[i], expected[i])) {
+			continue
+		}
+
+		if precision > 0 && equalNumbers(found[i], expected[i], math.Pow10(-precision)) {
+			continue
+		}
+
+		return VerdictWrongAnswer, fmt.Sprintf("token %v differs, expected %#v, found %#v", i+1, expected[i], found[i])
+	}
+
+	if len(found) != len(expected) {
+		return VerdictWrongAnswer, fmt.Sprintf("expected %v tokens, found %v", len(expected), len(found))
+	}
+
+	return VerdictAccepted, ""
+}
+
+func equalNumbers(found, expected string, eps float64) bool {
+	a, err := strconv.ParseFloat(found, 64)
+	if err != nil {
+		return false
+	}
+
+	b, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+
+	diff := math.Abs(a - b)
+	return diff <= eps || diff <= eps*math.Abs(b)
+}
+
+// CompareLines compares output line by line, trailing whitespace and empty lines at the end are ignored
+func CompareLines(output, answer []byte) (Verdict, string) {
+	found, expected := splitLines(output), splitLines(answer)
+
+	for i := 0; i < len(found) && i < len(expected); i++ {
+		if found[i] != expected[i] {
+			return VerdictWrongAnswer, fmt.Sprintf("line %v differs, expected %#v, found %#v", i+1, expected[i], found[i])
+		}
+	}
+
+	if len(found) != len(expected) {
+		return VerdictWrongAnswer, fmt.Sprintf("expected %v lines, found %v", len(expected), len(found))
+	}
+
+	return VerdictAccepted, ""
+}
+
+func splitLines(data []byte) []string {
+	lines := strings.Split(string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
@@ -0,0 +1,677 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/ecm"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KattisImporter imports problems in Kattis (ICPC) problem package format, see https://www.kattis.com/problem-package-format/
+type KattisImporter struct {
+	Importer
+	spec      *KattisSpecification
+	timeLimit float64 // time limit in seconds set outside problem.yaml, overrides .timelimit
+	path      string
+	context   context.Context
+	ts        *typewriter.TypewriterService
+	kpr       *keeper.KeeperService
+}
+
+// KattisSpecification is problem.yaml of the package
+type KattisSpecification struct {
+	Name           interface{}  `yaml:"name"`
+	Type           interface{}  `yaml:"type"`
+	Author         string       `yaml:"author"`
+	Source         string       `yaml:"source"`
+	Validation     string       `yaml:"validation"`
+	ValidatorFlags string       `yaml:"validator_flags"`
+	Limits         KattisLimits `yaml:"limits"`
+}
+
+type KattisLimits struct {
+	TimeLimit float64 `yaml:"time_limit"`
+	Memory    int     `yaml:"memory"`
+	Output    int     `yaml:"output"`
+}
+
+// KattisTestdata is testdata.yaml (test_group.yaml in newer packages) of a test group, settings of the parent
+// groups are inherited
+type KattisTestdata struct {
+	Range       string        `yaml:"range"`
+	AcceptScore string        `yaml:"accept_score"`
+	GraderFlags string        `yaml:"grader_flags"`
+	Scoring     KattisScoring `yaml:"scoring"`
+}
+
+type KattisScoring struct {
+	Score       float64 `yaml:"score"`
+	Aggregation string  `yaml:"aggregation"`
+}
+
+const KattisDefaultLocale = "en"
+const KattisDefaultMemory = 2048
+
+func CreateKattisImporter(path string, context context.Context, ts *typewriter.TypewriterService, kpr *keeper.KeeperService) (*KattisImporter, error) {
+	importer := new(KattisImporter)
+	importer.path = path
+	importer.context = context
+	importer.ts = ts
+	importer.kpr = kpr
+
+	spec, err := ReadKattisSpecification(path)
+	if err != nil {
+		return nil, err
+	}
+
+	importer.spec = spec
+
+	return importer, nil
+}
+
+// ReadKattisSpecification parses problem.yaml of the package
+func ReadKattisSpecification(path string) (*KattisSpecification, error) {
+	data, err := ioutil.ReadFile(filepath.Join(path, "problem.yaml"))
+	if err != nil {
+		log.Printf("Unable to read problem.yaml: %v", err)
+		return nil, err
+	}
+
+	spec := &KattisSpecification{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		log.Printf("Unable to parse problem.yaml: %v", err)
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// hasType checks problem type, both "type" of the current format and "validation" of the legacy one are supported
+func (imp KattisImporter) hasType(name string) bool {
+	var types []string
+	switch t := imp.spec.Type.(type) {
+	case string:
+		types = strings.Fields(t)
+	case []interface{}:
+		for _, v := range t {
+			types = append(types, fmt.Sprint(v))
+		}
+	}
+
+	types = append(types, strings.Fields(imp.spec.Validation)...)
+
+	for _, t := range types {
+		if t == name || (name == "scoring" && t == "score") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (imp KattisImporter) GetVerifier() (*executor.Verifier, error) {
+	if imp.HasInteractor() {
+		log.Println("Interactive problem, the output validator is imported as interactor")
+		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: true}, nil
+	}
+
+	if lang, source, ok, err := imp.readValidator(false); err != nil {
+		return nil, err
+	} else if ok {
+		return &executor.Verifier{Type: executor.Verifier_PROGRAM, Source: source, Lang: lang}, nil
+	}
+
+	if strings.HasPrefix(imp.spec.Validation, "custom") {
+		return nil, errors.New("custom output validator is not found")
+	}
+
+	// default validator, see validator_flags in the specification
+	verifier := &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: false}
+	flags := strings.Fields(imp.spec.ValidatorFlags)
+	for i := 0; i < len(flags); i++ {
+		switch flags[i] {
+		case "case_sensitive":
+			verifier.CaseSensitive = true
+		case "space_change_sensitive":
+			verifier.Type = executor.Verifier_LINES
+		case "float_tolerance", "float_absolute_tolerance", "float_relative_tolerance":
+			if i+1 >= len(flags) {
+				continue
+			}
+			i++
+
+			tolerance, err := strconv.ParseFloat(flags[i], 64)
+			if err != nil || tolerance <= 0 {
+				log.Printf("Unable to parse %v %#v", flags[i-1], flags[i])
+				continue
+			}
+
+			verifier.Type = executor.Verifier_TOKENS
+			verifier.Precision = int32(math.Round(-math.Log10(tolerance)))
+		}
+	}
+
+	return verifier, nil
+}
+
+func (imp KattisImporter) HasInteractor() bool {
+	return imp.hasType("interactive")
+}
+
+func (imp KattisImporter) GetInteractor() (*executor.Interactor, error) {
+	lang, source, ok, err := imp.readValidator(true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errors.New("interactor configuration is not supported")
+	}
+
+	return &executor.Interactor{Type: executor.Interactor_PROGRAM, Source: source, Lang: lang}, nil
+}
+
+// readValidator finds source of the output validator in output_validators/<name>/ or output_validator/, local
+// headers included by the validator are inlined and the source is wrapped into testlib adapter. Only C++ validators
+// can be adapted.
+func (imp KattisImporter) readValidator(interactive bool) (string, string, bool, error) {
+	var dirs []string
+	for _, root := range []string{"output_validators", "output_validator"} {
+		base := filepath.Join(imp.path, root)
+		files, err := ioutil.ReadDir(base)
+		if err != nil {
+			continue
+		}
+
+		dirs = append(dirs, base)
+		for _, file := range files {
+			if file.IsDir() {
+				dirs = append(dirs, filepath.Join(base, file.Name()))
+			}
+		}
+	}
+
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return "", "", false, err
+		}
+
+		var candidates []string
+		for _, file := range files {
+			if _, ok := LanguageByExtension(file.Name()); ok && !file.IsDir() {
+				candidates = append(candidates, file.Name())
+			}
+		}
+
+		if len(candidates) == 0 {
+			continue
+		}
+
+		// prefer files named like validator or checker when the validator consists of several files
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return isValidatorName(candidates[i]) && !isValidatorName(candidates[j])
+		})
+
+		name := candidates[0]
+		lang, _ := LanguageByExtension(name)
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", "", false, err
+		}
+
+		if !strings.HasPrefix(lang, "cpp") {
+			return "", "", false, fmt.Errorf("custom output validators in %v are not supported, only C++ validators can be adapted to testlib", lang)
+		}
+
+		log.Printf("Using output validator %v", filepath.Join(dir, name))
+
+		source := kattisValidatorAdapter(InlineIncludes(dir, string(data)), strings.Fields(imp.spec.ValidatorFlags), interactive)
+
+		return lang, source, true, nil
+	}
+
+	return "", "", false, nil
+}
+
+func isValidatorName(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "valid") || strings.Contains(name, "check")
+}
+
+var kattisProblemName = regexp.MustCompile(`\\problemname\{(.*)\}`)
+var kattisSection = regexp.MustCompile(`\\section\*?\{(Input|Output|Interaction|Scoring|Notes?)\}`)
+
+var kattisSections = map[string]string{
+	"Input":       "\\InputFile",
+	"Output":      "\\OutputFile",
+	"Interaction": "\\Interaction",
+	"Scoring":     "\\Scoring",
+	"Note":        "\\Note",
+	"Notes":       "\\Note",
+}
+
+func (imp KattisImporter) GetStatements(source string) ([]*atlas.Statement, error) {
+	if source == "" {
+		source = imp.spec.Source
+	}
+
+	var statements []*atlas.Statement
+	for _, dir := range []string{"problem_statement", "statement"} {
+		files, err := ioutil.ReadDir(filepath.Join(imp.path, dir))
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			locale, ok := kattisLocale(file.Name(), "problem")
+			if !ok {
+				continue
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join(imp.path, dir, file.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			content := string(data)
+
+			title := imp.name(locale)
+			if m := kattisProblemName.FindStringSubmatch(content); m != nil {
+				if title == "" {
+					title = strings.TrimSpace(m[1])
+				}
+				content = strings.Replace(content, m[0], "", 1)
+			}
+
+			content = kattisSection.ReplaceAllStringFunc(content, func(s string) string {
+				return kattisSections[kattisSection.FindStringSubmatch(s)[1]]
+			})
+
+			content, err = UpdateContentWithPictures(imp.context, imp.ts, strings.TrimSpace(content), filepath.Join(imp.path, dir)+"/")
+			if err != nil {
+				return nil, err
+			}
+
+			statements = append(statements, &atlas.Statement{
+				Locale:  locale,
+				Title:   title,
+				Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: content}},
+				Author:  imp.spec.Author,
+				Source:  source,
+			})
+		}
+	}
+
+	return statements, nil
+}
+
+// name returns problem name for the locale from problem.yaml
+func (imp KattisImporter) name(locale string) string {
+	switch name := imp.spec.Name.(type) {
+	case string:
+		return name
+	case map[string]interface{}:
+		if v, ok := name[locale]; ok {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// kattisLocale parses locale from <prefix>.<locale>.tex or <prefix>.tex file name
+func kattisLocale(name, prefix string) (string, bool) {
+	if filepath.Ext(name) != ".tex" || !strings.HasPrefix(name, prefix) {
+		return "", false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(name, ".tex"), ".")
+	switch {
+	case len(parts) == 1 && parts[0] == prefix:
+		return KattisDefaultLocale, true
+	case len(parts) == 2 && parts[0] == prefix:
+		if locale, err := MakeLocale(parts[1]); err == nil {
+			return locale, true
+		}
+		return parts[1], true
+	}
+
+	return "", false
+}
+
+func (imp KattisImporter) GetSolutions() ([]*atlas.Editorial, error) {
+	var editorials []*atlas.Editorial
+	for _, dir := range []string{"problem_statement", "statement", "solution"} {
+		files, err := ioutil.ReadDir(filepath.Join(imp.path, dir))
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			locale, ok := kattisLocale(file.Name(), "solution")
+			if !ok {
+				continue
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join(imp.path, dir, file.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			content, err := UpdateContentWithPictures(imp.context, imp.ts, string(data), filepath.Join(imp.path, dir)+"/")
+			if err != nil {
+				return nil, err
+			}
+
+			log.Println("Found solution", file.Name())
+			editorials = append(editorials, &atlas.Editorial{
+				Locale:  locale,
+				Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: content}},
+			})
+		}
+	}
+
+	return editorials, nil
+}
+
+// limits returns time limit in milliseconds, memory and output limits in bytes
+func (imp KattisImporter) limits() (uint32, uint64, uint64) {
+	seconds := imp.spec.Limits.TimeLimit
+	if data, err := ioutil.ReadFile(filepath.Join(imp.path, ".timelimit")); err == nil {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64); err == nil {
+			seconds = v
+		}
+	}
+
+	if imp.timeLimit > 0 {
+		seconds = imp.timeLimit
+	}
+
+	if seconds <= 0 {
+		log.Println("Time limit is not set in .timelimit or problem.yaml, using 1 second")
+		seconds = 1
+	}
+
+	memory := imp.spec.Limits.Memory
+	if memory <= 0 {
+		memory = KattisDefaultMemory
+	}
+
+	output := uint64(536870912)
+	if imp.spec.Limits.Output > 0 {
+		output = uint64(imp.spec.Limits.Output) * 1024 * 1024
+	}
+
+	return uint32(math.Round(seconds * 1000)), uint64(memory) * 1024 * 1024, output
+}
+
+func (imp KattisImporter) GetTestsets() ([]*Group, error) {
+	timeLimit, memoryLimit, outputLimit := imp.limits()
+
+	newTestset := func(index uint32) *atlas.Testset {
+		return &atlas.Testset{
+			Index:          index,
+			TimeLimit:      timeLimit,
+			MemoryLimit:    memoryLimit,
+			FileSizeLimit:  outputLimit,
+			ScoringMode:    atlas.ScoringMode_EACH,
+			FeedbackPolicy: atlas.FeedbackPolicy_COMPLETE,
+		}
+	}
+
+	var groups []*Group
+
+	samples, err := imp.uploadTests(filepath.Join(imp.path, "data", "sample"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(samples) > 0 {
+		for _, test := range samples {
+			test.Example = true
+			test.Score = 0
+		}
+		groups = append(groups, &Group{Testset: newTestset(0), Tests: samples, Name: 0})
+	}
+
+	secret := filepath.Join(imp.path, "data", "secret")
+	subgroups := kattisSubgroups(secret)
+
+	// pass-fail problems and scoring problems without test groups have a single testset
+	if !imp.hasType("scoring") || len(subgroups) == 0 {
+		tests, err := imp.uploadTests(secret)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tests) == 0 {
+			return nil, errors.New("no tests found in data/secret")
+		}
+
+		group := &Group{Testset: newTestset(1), Tests: tests, Name: 1}
+		if imp.hasType("scoring") {
+			setGroupScore(group, imp.testdata(secret), float64(100))
+		} else {
+			AddPointsToTests(group)
+		}
+
+		return append(groups, group), nil
+	}
+
+	for i, dir := range subgroups {
+		tests, err := imp.uploadTests(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tests) == 0 {
+			log.Printf("Test group %v has no tests, skipping", dir)
+			continue
+		}
+
+		index := uint32(i + 1)
+		group := &Group{Testset: newTestset(index), Tests: tests, Name: index}
+		setGroupScore(group, imp.testdata(dir), float64(len(tests)))
+
+		log.Printf("Test group %v is imported as testset %v", filepath.Base(dir), index)
+		groups = append(groups, group)
+	}
+
+	// tests right in data/secret are worth accept_score each and are imported into a testset after the groups
+	paths, err := GetTestPathsFromLocation(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) > 0 {
+		tests, err := UploadTestPaths(imp.context, imp.kpr, paths)
+		if err != nil {
+			return nil, err
+		}
+
+		index := uint32(len(subgroups) + 1)
+		group := &Group{Testset: newTestset(index), Tests: tests, Name: index}
+		setGroupScore(group, KattisTestdata{AcceptScore: imp.testdata(secret).AcceptScore}, float64(len(tests)))
+
+		log.Printf("%v tests of data/secret outside of test groups are imported as testset %v", len(tests), index)
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// uploadTests uploads tests of the group directory including nested groups
+func (imp KattisImporter) uploadTests(dir string) ([]*atlas.Test, error) {
+	paths, err := kattisTestPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	return UploadTestPaths(imp.context, imp.kpr, paths)
+}
+
+func kattisTestPaths(dir string) ([]TestPath, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	paths, err := GetTestPathsFromLocation(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sub := range kattisSubgroups(dir) {
+		nested, err := kattisTestPaths(sub)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, nested...)
+	}
+
+	return paths, nil
+}
+
+// kattisSubgroups returns nested test group directories sorted by name
+func kattisSubgroups(dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var groups []string
+	for _, file := range files {
+		if file.IsDir() {
+			groups = append(groups, filepath.Join(dir, file.Name()))
+		}
+	}
+
+	sort.Strings(groups)
+	return groups
+}
+
+// testdata reads settings of the test group, settings of the parent groups up to data/ are applied first
+func (imp KattisImporter) testdata(dir string) KattisTestdata {
+	data := filepath.Join(imp.path, "data")
+	rel, err := filepath.Rel(data, dir)
+	if err != nil {
+		rel = "."
+	}
+
+	var dirs []string
+	for d := rel; ; d = filepath.Dir(d) {
+		dirs = append([]string{filepath.Join(data, d)}, dirs...)
+		if d == "." || d == "/" {
+			break
+		}
+	}
+
+	result := KattisTestdata{}
+	for _, d := range dirs {
+		for _, name := range []string{"testdata.yaml", "test_group.yaml"} {
+			raw, err := ioutil.ReadFile(filepath.Join(d, name))
+			if err != nil {
+				continue
+			}
+
+			td := KattisTestdata{}
+			if err := yaml.Unmarshal(raw, &td); err != nil {
+				log.Printf("Unable to parse %v: %v", filepath.Join(d, name), err)
+				continue
+			}
+
+			if td.Range != "" {
+				result.Range = td.Range
+			}
+			if td.AcceptScore != "" {
+				result.AcceptScore = td.AcceptScore
+			}
+			if td.GraderFlags != "" {
+				result.GraderFlags = td.GraderFlags
+			}
+			if td.Scoring.Score != 0 {
+				result.Scoring.Score = td.Scoring.Score
+			}
+			if td.Scoring.Aggregation != "" {
+				result.Scoring.Aggregation = td.Scoring.Aggregation
+			}
+		}
+	}
+
+	return result
+}
+
+// Score returns maximum score of the group, fallback is used when the score is not specified
+func (td KattisTestdata) Score(tests int, fallback float64) float64 {
+	if td.Scoring.Score > 0 {
+		return td.Scoring.Score
+	}
+
+	if parts := strings.Fields(td.Range); len(parts) == 2 {
+		if v, err := strconv.ParseFloat(parts[1], 64); err == nil {
+			return v
+		}
+	}
+
+	if td.AcceptScore != "" {
+		if v, err := strconv.ParseFloat(td.AcceptScore, 64); err == nil {
+			return v * float64(tests)
+		}
+	}
+
+	return fallback
+}
+
+// Min reports whether the group score is the minimum of test scores, like Polygon groups with block_min
+func (td KattisTestdata) Min() bool {
+	if td.Scoring.Aggregation == "min" {
+		return true
+	}
+
+	for _, flag := range strings.Fields(td.GraderFlags) {
+		if flag == "min" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setGroupScore distributes group score between tests of the group
+func setGroupScore(group *Group, td KattisTestdata, fallback float64) {
+	score := td.Score(len(group.Tests), fallback)
+
+	if td.Min() {
+		group.Testset.ScoringMode = atlas.ScoringMode_WORST
+		for _, test := range group.Tests {
+			test.Score = float32(score)
+		}
+		return
+	}
+
+	for _, test := range group.Tests {
+		test.Score = float32(score / float64(len(group.Tests)))
+	}
+}
+
+func (imp KattisImporter) GetTemplates(pid *string) ([]*atlas.Template, error) {
+	return nil, nil
+}
+
+// GetAttachments uploads files from attachments/ which are provided to contestants
+func (imp KattisImporter) GetAttachments(pid *string) ([]*atlas.Attachment, error) {
+	return UploadAttachments(imp.context, imp.ts, filepath.Join(imp.path, "attachments"), *pid)
+}
@@ -0,0 +1,188 @@
+package types_test
+
+import (
+	"context"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"github.com/eolymp/polyglot/cmd/runner"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files of the package, names are relative to the directory
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const kattisValidator = `#include <fstream>
+#include <iostream>
+#include <cstdlib>
+#include <string>
+
+int main(int argc, char** argv) {
+	std::ifstream answer(argv[2]);
+	std::string expected, found;
+	answer >> expected;
+	std::cin >> found;
+
+	if (std::string(argv[4]) != "flag") {
+		return 1;
+	}
+
+	if (found != expected) {
+		std::ofstream(std::string(argv[3]) + "/judgemessage.txt") << "expected " << expected << ", found " << found;
+		exit(43);
+	}
+
+	return 42;
+}
+`
+
+func TestKattisValidatorAdapter(t *testing.T) {
+	if _, err := exec.LookPath(runner.DefaultCompiler); err != nil {
+		t.Skip("C++ compiler is not available")
+	}
+
+	tt := []struct {
+		name   string
+		create func(path string) (types.Importer, error)
+		files  map[string]string
+	}{
+		{
+			name: "kattis",
+			create: func(path string) (types.Importer, error) {
+				return types.CreateKattisImporter(path, context.Background(), nil, nil)
+			},
+			files: map[string]string{"problem.yaml": "validation: custom\nvalidator_flags: flag\n"},
+		},
+		{
+			name: "domjudge",
+			create: func(path string) (types.Importer, error) {
+				return types.CreateDomjudgeImporter(path, context.Background(), nil, nil)
+			},
+			files: map[string]string{"problem.yaml": "validation: custom\nvalidator_flags: flag\n", "domjudge-problem.ini": "name='Sum'\n"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tc.files["output_validators/check/validate.cpp"] = kattisValidator
+			tc.files["input"] = "1 2\n"
+			tc.files["answer"] = "3\n"
+			tc.files["correct"] = "3\n"
+			tc.files["wrong"] = "4\n"
+			writeFiles(t, dir, tc.files)
+
+			imp, err := tc.create(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verifier, err := imp.GetVerifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if verifier.GetType() != executor.Verifier_PROGRAM {
+				t.Fatalf("Verifier must be a program, got %v", verifier.GetType())
+			}
+
+			source := filepath.Join(dir, "checker.cpp")
+			if err := os.WriteFile(source, []byte(verifier.GetSource()), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			binary := filepath.Join(dir, "checker")
+			if err := runner.NewCompiler("", nil).Compile(context.Background(), source, binary); err != nil {
+				t.Fatal(err)
+			}
+
+			for output, code := range map[string]int{"correct": 0, "wrong": 1} {
+				// testlib checkers are called as "checker input output answer"
+				args := []string{filepath.Join(dir, "input"), filepath.Join(dir, output), filepath.Join(dir, "answer")}
+
+				result, err := runner.Run(context.Background(), binary, args, nil, 0, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if result.ExitCode != code {
+					t.Errorf("Checker exits with %v on %v output, expected %v", result.ExitCode, output, code)
+				}
+
+				if code == 1 && !strings.Contains(result.Message(), "expected 3, found 4") {
+					t.Errorf("Judge message is not printed, got %#v", result.Message())
+				}
+			}
+		})
+	}
+}
+
+func TestKattisValidatorLanguage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"problem.yaml":                    "validation: custom\n",
+		"output_validators/check/main.py": "import sys\nsys.exit(42)\n",
+	})
+
+	imp, err := types.CreateKattisImporter(dir, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := imp.GetVerifier(); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Python validator must be rejected, got %v", err)
+	}
+}
+
+func TestKattisSecretTestsOutsideGroups(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"problem.yaml":                     "name: Sum\ntype: scoring\n",
+		"data/sample/1.in":                 "1 2\n",
+		"data/sample/1.ans":                "3\n",
+		"data/secret/testdata.yaml":        "accept_score: 2\n",
+		"data/secret/loose.in":             "5 5\n",
+		"data/secret/loose.ans":            "10\n",
+		"data/secret/group1/testdata.yaml": "range: 0 40\n",
+		"data/secret/group1/1.in":          "1 1\n",
+		"data/secret/group1/1.ans":         "2\n",
+		"data/secret/group1/2.in":          "2 1\n",
+		"data/secret/group1/2.ans":         "3\n",
+	})
+
+	imp, err := types.CreateKattisImporter(dir, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := imp.GetTestsets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 3 {
+		t.Fatalf("Expected samples, the group and tests outside of groups, got %v testsets", len(groups))
+	}
+
+	if g := groups[1]; g.Name != 1 || len(g.Tests) != 2 || g.Tests[0].GetScore() != 20 {
+		t.Errorf("Group is imported as testset %v with %v tests, expected testset 1 with 2 tests of 20 points", g.Name, len(g.Tests))
+	}
+
+	if g := groups[2]; g.Name != 2 || len(g.Tests) != 1 || g.Tests[0].GetScore() != 2 {
+		t.Errorf("Tests outside of groups are imported as testset %v with %v tests, expected testset 2 with a test of 2 points", g.Name, len(g.Tests))
+	}
+}
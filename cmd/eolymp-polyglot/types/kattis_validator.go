@@ -0,0 +1,110 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// kattisValidatorAdapter wraps source of Kattis output validator, so it can be run as testlib checker or interactor.
+//
+// Kattis validators are called as "validator input answer feedback_dir [flags] < output" and report the verdict with
+// exit code 42 (accepted) or 43 (wrong answer). Eolymp calls checkers as "checker input output answer" and
+// interactors as "interactor input output answer", and expects testlib exit codes: 0 (accepted), 1 (wrong answer)
+// and 3 (failure). The adapter renames main of the validator, converts the arguments and maps exit codes, including
+// the ones passed to exit(). Judge messages written to the feedback directory are printed to stderr.
+//
+// With interactor the output file is not produced by the solution, so the answer is copied there when the
+// interaction is accepted and the verifier compares it with the answer.
+func kattisValidatorAdapter(source string, flags []string, interactive bool) string {
+	var args []string
+	for _, flag := range flags {
+		args = append(args, strconv.Quote(flag))
+	}
+
+	return fmt.Sprintf(kattisAdapterTemplate, source, strings.Join(args, ", "), interactive)
+}
+
+const kattisAdapterTemplate = `#include <bits/stdc++.h>
+
+static const bool kattis_interactive = %[3]v;
+static std::string kattis_feedback_dir = ".", kattis_output, kattis_answer;
+
+// kattis_finish maps exit code of the validator to testlib one
+static int kattis_finish(int code) {
+	std::fflush(stdout);
+
+	{
+		std::ifstream message(kattis_feedback_dir + "/judgemessage.txt");
+		if (message) {
+			std::cerr << message.rdbuf();
+		}
+	}
+
+	if (kattis_feedback_dir != ".") {
+		std::error_code ignored;
+		std::filesystem::remove_all(kattis_feedback_dir, ignored);
+	}
+
+	switch (code) {
+	case 42:
+		if (kattis_interactive) {
+			std::ifstream src(kattis_answer, std::ios::binary);
+			std::ofstream dst(kattis_output, std::ios::binary);
+			dst << src.rdbuf();
+		}
+		return 0;
+	case 43:
+		return 1;
+	default:
+		return 3;
+	}
+}
+
+[[noreturn]] static void kattis_exit(int code) {
+	std::exit(kattis_finish(code));
+}
+
+namespace std {
+	[[noreturn]] static void kattis_exit(int code) {
+		::kattis_exit(code);
+	}
+}
+
+#define exit kattis_exit
+#define main kattis_validator_main
+
+%[1]s
+
+#undef main
+#undef exit
+
+int main(int argc, char* argv[]) {
+	if (argc < 3) {
+		std::cerr << "usage: " << argv[0] << " input output [answer]" << std::endl;
+		return 3;
+	}
+
+	char dir[] = "/tmp/kattis-feedback-XXXXXX";
+	if (mkdtemp(dir)) {
+		kattis_feedback_dir = dir;
+	}
+
+	kattis_output = argv[2];
+	kattis_answer = argc > 3 ? argv[3] : "/dev/null";
+
+	if (!kattis_interactive && !std::freopen(kattis_output.c_str(), "r", stdin)) {
+		std::cerr << "unable to open " << kattis_output << std::endl;
+		return 3;
+	}
+
+	std::vector<std::string> args = {argv[0], argv[1], kattis_answer, kattis_feedback_dir, %[2]s};
+	std::vector<char*> ptrs;
+	for (auto& arg : args) {
+		ptrs.push_back(&arg[0]);
+	}
+	ptrs.push_back(nullptr);
+
+	return kattis_finish(kattis_validator_main((int)args.size(), ptrs.data()));
+}
+`
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+// Parallel calls fn for every index in [0, n) using JobsFromContext(ctx) workers. Results should be stored by
+// index to keep them in order. After the first error remaining indexes are skipped, context passed to fn is
+// cancelled and the error is returned once all running calls finish.
+func Parallel(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := JobsFromContext(ctx)
+	if jobs > n {
+		jobs = n
+	}
+
+	indexes := make(chan int)
+
+	var once sync.Once
+	var first error
+
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						first = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case indexes <- i:
+		}
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	if first != nil {
+		return first
+	}
+
+	return ctx.Err()
+}
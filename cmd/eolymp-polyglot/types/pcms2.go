@@ -0,0 +1,495 @@
+package types
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/ecm"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pcms2Importer imports PCMS2 problems used by NEERC-style contests. Such problems have problem.xml which is
+// different from Polygon one, tests are described by href patterns and statements are in statements/.
+type Pcms2Importer struct {
+	Importer
+	spec    *Pcms2Specification
+	path    string
+	context context.Context
+	ts      *typewriter.TypewriterService
+	kpr     *keeper.KeeperService
+}
+
+func CreatePcms2Importer(path string, context context.Context, ts *typewriter.TypewriterService, kpr *keeper.KeeperService) (*Pcms2Importer, error) {
+	importer := new(Pcms2Importer)
+	importer.path = path
+	importer.context = context
+	importer.ts = ts
+	importer.kpr = kpr
+
+	spec, err := ReadPcms2Specification(path)
+	if err != nil {
+		return nil, err
+	}
+
+	importer.spec = spec
+
+	if testsets := importer.testsets(); len(testsets) > 1 {
+		log.Printf("%v testsets defined in problem.xml, only the first one will be imported", len(testsets))
+	}
+
+	return importer, nil
+}
+
+// ReadPcms2Specification parses problem.xml of the PCMS2 problem
+func ReadPcms2Specification(path string) (*Pcms2Specification, error) {
+	specf, err := os.Open(filepath.Join(path, "problem.xml"))
+	if err != nil {
+		log.Printf("Unable to open problem.xml: %v", err)
+		return nil, err
+	}
+
+	defer func() {
+		_ = specf.Close()
+	}()
+
+	spec := &Pcms2Specification{}
+	if err := xml.NewDecoder(specf).Decode(spec); err != nil {
+		log.Printf("Unable to parse problem.xml: %v", err)
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// testsets returns testsets of the judging script, older problems have them right in <judging>
+func (imp Pcms2Importer) testsets() []Pcms2Testset {
+	return append(imp.spec.Judging.Script.Testsets, imp.spec.Judging.Testsets...)
+}
+
+func (imp Pcms2Importer) verifier() Pcms2Program {
+	if v := imp.spec.Judging.Script.Verifier; len(v.Binaries) > 0 || len(v.Sources) > 0 {
+		return v
+	}
+	return imp.spec.Judging.Verifier
+}
+
+func (imp Pcms2Importer) interactor() Pcms2Program {
+	if v := imp.spec.Judging.Script.Interactor; len(v.Binaries) > 0 || len(v.Sources) > 0 {
+		return v
+	}
+	return imp.spec.Judging.Interactor
+}
+
+// readProgram returns source code of the verifier or interactor, if problem.xml only refers to a binary, the source
+// with the same name next to it is used (check.exe is built from check.cpp, check.dpr and so on)
+func (imp Pcms2Importer) readProgram(program Pcms2Program) (string, string, bool, error) {
+	for lang, types := range mapping {
+		source, ok := SourceByType(program.Sources, types...)
+		if !ok {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(imp.path, source.Path))
+		if err != nil {
+			return "", "", false, err
+		}
+
+		return lang, InlineIncludes(filepath.Join(imp.path, filepath.Dir(source.Path)), string(data)), true, nil
+	}
+
+	for _, source := range program.Sources {
+		if lang, ok := LanguageByExtension(source.Path); ok {
+			data, err := ioutil.ReadFile(filepath.Join(imp.path, source.Path))
+			if err != nil {
+				return "", "", false, err
+			}
+
+			return lang, InlineIncludes(filepath.Join(imp.path, filepath.Dir(source.Path)), string(data)), true, nil
+		}
+	}
+
+	for _, binary := range program.Binaries {
+		name := strings.TrimSuffix(filepath.Base(binary.File), filepath.Ext(binary.File))
+		if name == "" {
+			continue
+		}
+
+		lang, source, ok, err := readProgram(filepath.Join(imp.path, filepath.Dir(binary.File)), name+".")
+		if err != nil || ok {
+			return lang, source, ok, err
+		}
+	}
+
+	return "", "", false, nil
+}
+
+func (imp Pcms2Importer) GetVerifier() (*executor.Verifier, error) {
+	verifier := imp.verifier()
+	if len(verifier.Binaries) == 0 && len(verifier.Sources) == 0 {
+		log.Println("Verifier is not defined in problem.xml, tokens are compared")
+		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: true}, nil
+	}
+
+	lang, source, ok, err := imp.readProgram(verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errors.New("checker configuration is not supported")
+	}
+
+	return &executor.Verifier{Type: executor.Verifier_PROGRAM, Source: source, Lang: lang}, nil
+}
+
+func (imp Pcms2Importer) HasInteractor() bool {
+	interactor := imp.interactor()
+	return len(interactor.Binaries) > 0 || len(interactor.Sources) > 0
+}
+
+func (imp Pcms2Importer) GetInteractor() (*executor.Interactor, error) {
+	lang, source, ok, err := imp.readProgram(imp.interactor())
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errors.New("interactor configuration is not supported")
+	}
+
+	return &executor.Interactor{Type: executor.Interactor_PROGRAM, Source: source, Lang: lang}, nil
+}
+
+// GetStatements reads LaTeX statements from statements/ (or statement/), either as statements/<language>/*.tex or
+// as statements/*.tex with language in the file name. PDF statements are uploaded when there is no LaTeX source.
+func (imp Pcms2Importer) GetStatements(source string) ([]*atlas.Statement, error) {
+	for _, dir := range []string{"statements", "statement"} {
+		statements, err := imp.readStatements(filepath.Join(imp.path, dir), "en", source)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(statements) > 0 {
+			return statements, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (imp Pcms2Importer) readStatements(dir, locale, source string) ([]*atlas.Statement, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var statements []*atlas.Statement
+	var pdfs []string
+	for _, file := range files {
+		if file.IsDir() {
+			nested, err := imp.readStatements(filepath.Join(dir, file.Name()), LocaleFromFileName(file.Name(), locale), source)
+			if err != nil {
+				return nil, err
+			}
+
+			statements = append(statements, nested...)
+			continue
+		}
+
+		switch filepath.Ext(file.Name()) {
+		case ".pdf":
+			pdfs = append(pdfs, file.Name())
+			continue
+		case ".tex":
+		default:
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		title, content := olympStatement(string(data))
+		if title == "" {
+			title = imp.spec.Name
+		}
+
+		content, err = UpdateContentWithPictures(imp.context, imp.ts, content, dir+"/")
+		if err != nil {
+			return nil, err
+		}
+
+		statements = append(statements, &atlas.Statement{
+			Locale:  LocaleFromFileName(file.Name(), locale),
+			Title:   title,
+			Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: content}},
+			Source:  source,
+		})
+	}
+
+	if len(statements) > 0 {
+		return statements, nil
+	}
+
+	for _, name := range pdfs {
+		statement, err := UploadPdfStatement(imp.context, imp.ts, filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		statement.Locale = LocaleFromFileName(name, locale)
+		statement.Title = imp.spec.Name
+		statement.Source = source
+
+		statements = append(statements, statement)
+	}
+
+	return statements, nil
+}
+
+var olympExample = regexp.MustCompile(`\\exmp(file)?\{`)
+
+// olympStatement extracts title and text of the problem written with olymp.sty, examples are removed since they are
+// imported as tests
+func olympStatement(data string) (string, string) {
+	title := ""
+	if header, end := olympHeader(data); end >= 0 {
+		if len(header) > 0 {
+			title = header[0]
+		}
+		data = data[end:]
+	}
+
+	if i := strings.Index(data, "\\end{problem}"); i >= 0 {
+		data = data[:i]
+	}
+
+	if i := strings.Index(data, "\\Example"); i >= 0 {
+		data = data[:i]
+	}
+
+	return title, strings.TrimSpace(data)
+}
+
+// exampleCount returns the number of examples listed with \exmp and \exmpfile in LaTeX statements, examples are the
+// first tests of the problem. Since examples are cut from the statement, test 1 is the example if none are listed.
+func (imp Pcms2Importer) exampleCount() int {
+	count := 0
+	for _, dir := range []string{"statements", "statement"} {
+		_ = filepath.Walk(filepath.Join(imp.path, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || filepath.Ext(path) != ".tex" {
+				return nil
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				log.Printf("Unable to read %v: %v", path, err)
+				return nil
+			}
+
+			if n := len(olympExample.FindAllString(string(data), -1)); n > count {
+				count = n
+			}
+
+			return nil
+		})
+	}
+
+	if count == 0 {
+		return 1
+	}
+
+	return count
+}
+
+func (imp Pcms2Importer) GetSolutions() ([]*atlas.Editorial, error) {
+	return nil, nil
+}
+
+func (imp Pcms2Importer) GetTestsets() ([]*Group, error) {
+	testsets := imp.testsets()
+	if len(testsets) == 0 {
+		return nil, nil
+	}
+
+	testset := testsets[0]
+
+	timeLimit, err := parsePcms2Duration(testset.TimeLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	memoryLimit, err := parsePcms2Size(testset.MemoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	count := testset.TestCount
+	if count == 0 {
+		count = len(testset.Tests)
+	}
+
+	var paths []TestPath
+	for i := 1; i <= count; i++ {
+		paths = append(paths, TestPath{
+			input:  filepath.Join(imp.path, pcms2Href(testset.InputHref, i)),
+			output: filepath.Join(imp.path, pcms2Href(testset.AnswerHref, i)),
+		})
+	}
+
+	tests, err := UploadTestPaths(imp.context, imp.kpr, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	makeGroup := func(index uint32) *Group {
+		return &Group{Name: index, Testset: &atlas.Testset{
+			Index:          index,
+			TimeLimit:      timeLimit,
+			MemoryLimit:    memoryLimit,
+			FileSizeLimit:  536870912,
+			ScoringMode:    atlas.ScoringMode_EACH,
+			FeedbackPolicy: atlas.FeedbackPolicy_COMPLETE,
+		}}
+	}
+
+	// ICPC problems have all tests in one testset, scored by the number of passed tests
+	if len(testset.Tests) == 0 {
+		group := makeGroup(1)
+		group.Testset.FeedbackPolicy = atlas.FeedbackPolicy_ICPC_EXPANDED
+		group.Tests = tests
+		AddPointsToTests(group)
+
+		examples := imp.exampleCount()
+		for i, test := range tests {
+			test.Example = i < examples
+		}
+
+		return []*Group{group}, nil
+	}
+
+	if len(testset.Tests) != count {
+		log.Printf("Testset has %v tests, but points are given for %v", count, len(testset.Tests))
+	}
+
+	var groups []*Group
+	byIndex := map[uint32]*Group{}
+	for i, test := range tests {
+		index := uint32(1)
+		points := float32(0)
+		if i < len(testset.Tests) {
+			points = testset.Tests[i].Points
+			if g, err := strconv.ParseUint(testset.Tests[i].Group, 10, 32); err == nil {
+				index = uint32(g)
+			}
+		}
+
+		group, ok := byIndex[index]
+		if !ok {
+			group = makeGroup(index)
+			byIndex[index] = group
+			groups = append(groups, group)
+		}
+
+		test.Index = int32(len(group.Tests) + 1)
+		test.Score = points
+		test.Example = index == 0 && points == 0
+		group.Tests = append(group.Tests, test)
+	}
+
+	return groups, nil
+}
+
+// pcms2Href replaces the run of # in the pattern with the test number padded with zeros to its length, so
+// "tests/##.a" becomes "tests/01.a"
+func pcms2Href(pattern string, n int) string {
+	start := strings.Index(pattern, "#")
+	if start < 0 {
+		return pattern
+	}
+
+	end := start
+	for end < len(pattern) && pattern[end] == '#' {
+		end++
+	}
+
+	return pattern[:start] + fmt.Sprintf("%0*d", end-start, n) + pattern[end:]
+}
+
+// parsePcms2Duration parses time limit like "2s", "1.5s" or "500ms" into milliseconds, numbers without unit are
+// seconds
+func parsePcms2Duration(value string) (uint32, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		log.Println("Time limit is not set in problem.xml, using 1 second")
+		return 1000, nil
+	}
+
+	multiplier := 1000.0
+	if strings.HasSuffix(value, "ms") {
+		multiplier = 1
+		value = strings.TrimSuffix(value, "ms")
+	} else {
+		value = strings.TrimSuffix(value, "s")
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time limit %#v: %w", value, err)
+	}
+
+	return uint32(v * multiplier), nil
+}
+
+// parsePcms2Size parses memory limit like "256M", "64m" or "1G" into bytes, numbers without unit are bytes
+func parsePcms2Size(value string) (uint64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		log.Println("Memory limit is not set in problem.xml, using 256 MB")
+		return 256 * 1024 * 1024, nil
+	}
+
+	multiplier := 1.0
+	switch value[len(value)-1] {
+	case 'K':
+		multiplier = 1024
+	case 'M':
+		multiplier = 1024 * 1024
+	case 'G':
+		multiplier = 1024 * 1024 * 1024
+	}
+
+	if multiplier != 1 {
+		value = value[:len(value)-1]
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory limit %#v: %w", value, err)
+	}
+
+	return uint64(v * multiplier), nil
+}
+
+func (imp Pcms2Importer) GetTemplates(pid *string) ([]*atlas.Template, error) {
+	return nil, nil
+}
+
+func (imp Pcms2Importer) GetAttachments(pid *string) ([]*atlas.Attachment, error) {
+	return nil, nil
+}
@@ -0,0 +1,205 @@
+package types_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"testing"
+)
+
+func TestPcms2Limits(t *testing.T) {
+	tt := []struct {
+		name   string
+		time   string
+		memory string
+		wantTL uint32
+		wantML uint64
+		fails  bool
+	}{
+		{name: "seconds", time: "2s", memory: "256M", wantTL: 2000, wantML: 256 << 20},
+		{name: "fraction", time: "1.5s", memory: "64m", wantTL: 1500, wantML: 64 << 20},
+		{name: "milliseconds", time: "500ms", memory: "1G", wantTL: 500, wantML: 1 << 30},
+		{name: "no units", time: "3", memory: "1048576", wantTL: 3000, wantML: 1 << 20},
+		{name: "kilobytes", time: " 1s ", memory: "65536K", wantTL: 1000, wantML: 64 << 20},
+		{name: "defaults", wantTL: 1000, wantML: 256 << 20},
+		{name: "invalid time", time: "fast", memory: "256M", fails: true},
+		{name: "invalid memory", time: "1s", memory: "lots", fails: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{
+				"problem.xml": fmt.Sprintf(`<problem id="sum"><judging><script type="%%icpc"><testset test-count="1" input-href="tests/##" answer-href="tests/##.a" time-limit="%v" memory-limit="%v"/></script></judging></problem>`, tc.time, tc.memory),
+				"tests/01":    "1 2\n",
+				"tests/01.a":  "3\n",
+			})
+
+			imp, err := types.CreatePcms2Importer(dir, context.Background(), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			groups, err := imp.GetTestsets()
+			if tc.fails {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(groups) != 1 {
+				t.Fatalf("Expected a single testset, got %v", len(groups))
+			}
+
+			if got := groups[0].Testset.GetTimeLimit(); got != tc.wantTL {
+				t.Errorf("Time limit is %v, expected %v", got, tc.wantTL)
+			}
+
+			if got := groups[0].Testset.GetMemoryLimit(); got != tc.wantML {
+				t.Errorf("Memory limit is %v, expected %v", got, tc.wantML)
+			}
+		})
+	}
+}
+
+func TestPcms2Href(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		answer string
+		files  func(n int) (string, string)
+	}{
+		{
+			name:   "padded",
+			input:  "tests/##",
+			answer: "tests/##.a",
+			files:  func(n int) (string, string) { return fmt.Sprintf("tests/%02d", n), fmt.Sprintf("tests/%02d.a", n) },
+		},
+		{
+			name:   "single",
+			input:  "tests/#.in",
+			answer: "tests/#.out",
+			files:  func(n int) (string, string) { return fmt.Sprintf("tests/%d.in", n), fmt.Sprintf("tests/%d.out", n) },
+		},
+		{
+			name:   "wide",
+			input:  "t###/input",
+			answer: "t###/answer",
+			files:  func(n int) (string, string) { return fmt.Sprintf("t%03d/input", n), fmt.Sprintf("t%03d/answer", n) },
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{
+				"problem.xml": fmt.Sprintf(`<problem id="sum"><judging><script type="%%icpc"><testset test-count="12" input-href="%v" answer-href="%v"/></script></judging></problem>`, tc.input, tc.answer),
+			}
+			for n := 1; n <= 12; n++ {
+				input, answer := tc.files(n)
+				files[input] = fmt.Sprintf("input %d\n", n)
+				files[answer] = fmt.Sprintf("answer %d\n", n)
+			}
+			writeFiles(t, dir, files)
+
+			imp, err := types.CreatePcms2Importer(dir, context.Background(), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			groups, err := imp.GetTestsets()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(groups) != 1 || len(groups[0].Tests) != 12 {
+				t.Fatalf("Expected a testset with 12 tests, got %v", groups)
+			}
+
+			for i, test := range groups[0].Tests {
+				input, _ := types.MakeObjectByData([]byte(fmt.Sprintf("input %d\n", i+1)), nil)
+				answer, _ := types.MakeObjectByData([]byte(fmt.Sprintf("answer %d\n", i+1)), nil)
+
+				if test.GetInputObjectId() != input || test.GetAnswerObjectId() != answer {
+					t.Errorf("Test %v is read from wrong files", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestPcms2Examples(t *testing.T) {
+	tt := []struct {
+		name      string
+		statement string
+		examples  int
+	}{
+		{
+			name:      "listed",
+			statement: "\\begin{problem}{Sum of \\textit{a} and \\textit{b}}{sum.in}{sum.out}{1 second}{256 megabytes}\nFind a + b.\n\\Examples\n\\exmp{1 2\n}{3\n}\n\\exmpfile{example.02}{example.02.a}\n\\end{problem}\n",
+			examples:  2,
+		},
+		{
+			name:      "not listed",
+			statement: "\\begin{problem}{Sum of \\textit{a} and \\textit{b}}{sum.in}{sum.out}{1 second}{256 megabytes}\nFind a + b.\n\\Example\n\\end{problem}\n",
+			examples:  1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{
+				"problem.xml":                    `<problem id="sum"><judging><script type="%icpc"><testset test-count="3" input-href="tests/##" answer-href="tests/##.a"/></script></judging></problem>`,
+				"statements/english/problem.tex": tc.statement,
+			}
+			for n := 1; n <= 3; n++ {
+				files[fmt.Sprintf("tests/%02d", n)] = fmt.Sprintf("pcms2 %v %v\n", tc.name, n)
+				files[fmt.Sprintf("tests/%02d.a", n)] = fmt.Sprintf("pcms2 %v answer %v\n", tc.name, n)
+			}
+			writeFiles(t, dir, files)
+
+			imp, err := types.CreatePcms2Importer(dir, context.Background(), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			statements, err := imp.GetStatements("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(statements) != 1 {
+				t.Fatalf("Expected a single statement, got %v", len(statements))
+			}
+
+			if got := statements[0].GetTitle(); got != "Sum of \\textit{a} and \\textit{b}" {
+				t.Errorf("Title is %#v", got)
+			}
+
+			if got := statements[0].GetContent().GetLatex(); got != "Find a + b." {
+				t.Errorf("Statement is %#v, expected examples to be removed", got)
+			}
+
+			groups, err := imp.GetTestsets()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(groups) != 1 || len(groups[0].Tests) != 3 {
+				t.Fatalf("Expected a testset with 3 tests, got %v", groups)
+			}
+
+			for i, test := range groups[0].Tests {
+				if test.GetExample() != (i < tc.examples) {
+					t.Errorf("Test %v is example: %v, expected %v", i+1, test.GetExample(), i < tc.examples)
+				}
+			}
+		})
+	}
+}
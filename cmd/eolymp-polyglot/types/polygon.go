@@ -1,6 +1,7 @@
 package types
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"encoding/xml"
@@ -11,14 +12,17 @@ import (
 	"github.com/eolymp/go-sdk/eolymp/executor"
 	"github.com/eolymp/go-sdk/eolymp/keeper"
 	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"github.com/eolymp/polyglot/cmd/runner"
 	"golang.org/x/exp/slices"
 	"io/ioutil"
 	"log"
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type PolygonImporter struct {
@@ -28,6 +32,8 @@ type PolygonImporter struct {
 	context context.Context
 	ts      *typewriter.TypewriterService
 	kpr     *keeper.KeeperService
+	policy  string
+	slots   map[string]uint32
 }
 
 func CreatePolygonImporter(path string, context context.Context, ts *typewriter.TypewriterService, kpr *keeper.KeeperService) (*PolygonImporter, error) {
@@ -36,13 +42,31 @@ func CreatePolygonImporter(path string, context context.Context, ts *typewriter.
 	p.context = context
 	p.ts = ts
 	p.kpr = kpr
+	p.policy = TestsetPolicyMain
+	p.slots = map[string]uint32{}
 
+	spec, err := ReadPolygonSpecification(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p.spec = spec
+
+	if len(p.spec.Judging.Testsets) > 1 {
+		log.Printf("%v testsets defined in problem.xml", len(p.spec.Judging.Testsets))
+	}
+
+	return p, nil
+}
+
+// ReadPolygonSpecification parses problem.xml of the Polygon package
+func ReadPolygonSpecification(path string) (*Specification, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		log.Printf("Import path %#v is invalid: %v", path, err)
 		return nil, err
 	}
 
-	p.spec = &Specification{}
+	spec := &Specification{}
 
 	specf, err := os.Open(filepath.Join(path, "problem.xml"))
 	if err != nil {
@@ -54,16 +78,12 @@ func CreatePolygonImporter(path string, context context.Context, ts *typewriter.
 		_ = specf.Close()
 	}()
 
-	if err := xml.NewDecoder(specf).Decode(&p.spec); err != nil {
+	if err := xml.NewDecoder(specf).Decode(spec); err != nil {
 		log.Printf("Unable to parse problem.xml: %v", err)
 		return nil, err
 	}
 
-	if len(p.spec.Judging.Testsets) > 1 {
-		log.Printf("More than 1 testset defined in problem.xml, only first one will be imported")
-	}
-
-	return p, nil
+	return spec, nil
 }
 
 var mapping = map[string][]string{
@@ -82,44 +102,29 @@ var mapping = map[string][]string{
 }
 
 func (imp PolygonImporter) GetVerifier() (*executor.Verifier, error) {
-	switch imp.spec.Checker.Name {
-	case "std::rcmp4.cpp", // Single or more double, max any error 1E-4
-		"std::ncmp.cpp": // Single or more int64, ignores whitespaces
-		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 4, CaseSensitive: true}, nil
-	case "std::rcmp6.cpp": // Single or more double, max any error 1E-6
-		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 6, CaseSensitive: true}, nil
-	case "std::rcmp9.cpp": // Single or more double, max any error 1E-9
-		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 9, CaseSensitive: true}, nil
-	case "std::wcmp.cpp": // Sequence of tokens
-		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 5, CaseSensitive: true}, nil
-	case "std::nyesno.cpp", // Zero or more yes/no, case insensetive
-		"std::yesno.cpp": // Single yes or no, case insensetive
-		return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 5, CaseSensitive: false}, nil
-	case "std::fcmp.cpp", // Lines, doesn't ignore whitespaces
-		"std::hcmp.cpp", // Single huge integer
-		"std::lcmp.cpp": // Lines, ignores whitespaces
-		return &executor.Verifier{Type: executor.Verifier_LINES}, nil
-	default:
+	if verifier, ok := TestlibVerifier(imp.spec.Checker.Name); ok {
+		return verifier, nil
+	}
 
-		for lang, types := range mapping {
-			source, ok := SourceByType(imp.spec.Checker.Sources, types...)
-			if !ok {
-				continue
-			}
+	for lang, types := range mapping {
+		source, ok := SourceByType(imp.spec.Checker.Sources, types...)
+		if !ok {
+			continue
+		}
 
-			log.Printf("Unknown checker name %#v, using source code", imp.spec.Checker.Name)
+		log.Printf("Unknown checker name %#v, using source code", imp.spec.Checker.Name)
 
-			data, err := ioutil.ReadFile(filepath.Join(imp.path, source.Path))
-			if err != nil {
-				return nil, err
-			}
-
-			return &executor.Verifier{
-				Type:   executor.Verifier_PROGRAM,
-				Source: string(data), // todo: actually read file
-				Lang:   lang,
-			}, nil
+		data, err := ioutil.ReadFile(filepath.Join(imp.path, source.Path))
+		if err != nil {
+			return nil, err
 		}
+
+		// testlib.h and other local headers are inlined, since Eolymp compiles a single file
+		return &executor.Verifier{
+			Type:   executor.Verifier_PROGRAM,
+			Source: InlineIncludes(filepath.Join(imp.path, filepath.Dir(source.Path)), string(data)),
+			Lang:   lang,
+		}, nil
 	}
 
 	return nil, errors.New("checker configuration is not supported")
@@ -146,7 +151,7 @@ func (imp PolygonImporter) GetInteractor() (*executor.Interactor, error) {
 
 		return &executor.Interactor{
 			Type:   executor.Interactor_PROGRAM,
-			Source: string(data), // todo: actually read file
+			Source: InlineIncludes(filepath.Join(imp.path, filepath.Dir(source.Path)), string(data)),
 			Lang:   lang,
 		}, nil
 	}
@@ -244,7 +249,8 @@ func (imp PolygonImporter) GetSolutions() ([]*atlas.Editorial, error) {
 		}
 		locale, err := MakeLocale(solution.Language)
 		if err != nil {
-			return nil, err
+			log.Printf("Tutorial %v is skipped: %v", solution.Path, err)
+			continue
 		}
 
 		propdata, err := ioutil.ReadFile(filepath.Join(imp.path, filepath.Dir(solution.Path), "problem-properties.json"))
@@ -258,193 +264,440 @@ func (imp PolygonImporter) GetSolutions() ([]*atlas.Editorial, error) {
 			return nil, fmt.Errorf("unable to unmrashal problem-properties.json: %w", err)
 		}
 
-		parts := []string{props.Solution}
-		if props.Input != "" {
-			parts = append(parts, fmt.Sprintf("\\InputFile\n\n%v", props.Input))
+		if props.Solution == "" {
+			log.Printf("Tutorial %v is skipped: it is empty", solution.Path)
+			continue
+		}
+
+		content, err := UpdateContentWithPictures(imp.context, imp.ts, props.Solution, imp.path+"/statements/"+solution.Language+"/")
+		if err != nil {
+			return nil, err
 		}
+
 		solutions = append(solutions, &atlas.Editorial{
 			Locale:  locale,
-			Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: props.Solution}},
+			Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: content}},
 		})
 	}
 
 	return solutions, nil
 }
 
+// TestsetIndexRange is the number of Atlas testset indexes reserved for each
+// Polygon testset when TestsetPolicySplit is used.
+const TestsetIndexRange = 100
+
+const (
+	TestsetPolicyMain  = "main"  // import only the testset named "tests" (or the first one)
+	TestsetPolicySplit = "split" // import every testset into its own range of Atlas testsets
+	TestsetPolicyMerge = "merge" // import every testset, appending tests of the same group together
+)
+
+// SetTestsetPolicy configures how multiple Polygon testsets are imported. Slots
+// map a Polygon testset name to its position assigned by a previous import.
+func (imp *PolygonImporter) SetTestsetPolicy(policy string, slots map[string]uint32) error {
+	switch policy {
+	case TestsetPolicyMain, TestsetPolicySplit, TestsetPolicyMerge:
+	default:
+		return fmt.Errorf("unknown testset policy %#v", policy)
+	}
+
+	imp.policy = policy
+	imp.slots = map[string]uint32{}
+	for name, slot := range slots {
+		imp.slots[name] = slot
+	}
+
+	return nil
+}
+
+// TestsetSlots returns the position of every Polygon testset, it should be
+// stored and passed to SetTestsetPolicy on the next import of the problem.
+func (imp PolygonImporter) TestsetSlots() (map[string]uint32, error) {
+	testsets, err := imp.orderedTestsets()
+	if err != nil {
+		return nil, err
+	}
+
+	slots := map[string]uint32{}
+	for _, testset := range testsets {
+		slots[testset.Name] = imp.slots[testset.Name]
+	}
+	return slots, nil
+}
+
+// orderedTestsets assigns a slot to every testset which does not have one yet
+// and returns testsets ordered by slot. Main testset gets slot 0 if it is free.
+func (imp PolygonImporter) orderedTestsets() ([]SpecificationTestset, error) {
+	main, err := imp.mainTestset()
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[uint32]bool{}
+	for _, testset := range imp.spec.Judging.Testsets {
+		if slot, ok := imp.slots[testset.Name]; ok {
+			used[slot] = true
+		}
+	}
+
+	if _, ok := imp.slots[main.Name]; !ok && !used[0] {
+		imp.slots[main.Name] = 0
+		used[0] = true
+	}
+
+	next := uint32(0)
+	for _, testset := range imp.spec.Judging.Testsets {
+		if _, ok := imp.slots[testset.Name]; ok {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		imp.slots[testset.Name] = next
+		used[next] = true
+	}
+
+	testsets := append([]SpecificationTestset(nil), imp.spec.Judging.Testsets...)
+	sort.SliceStable(testsets, func(i, j int) bool {
+		return imp.slots[testsets[i].Name] < imp.slots[testsets[j].Name]
+	})
+
+	return testsets, nil
+}
+
+// mainTestset returns testset named "tests", or the first testset if there is no such
+func (imp PolygonImporter) mainTestset() (SpecificationTestset, error) {
+	if len(imp.spec.Judging.Testsets) == 0 {
+		return SpecificationTestset{}, errors.New("no testsets are defined in problem.xml")
+	}
+
+	testset := imp.spec.Judging.Testsets[0]
+	for _, test := range imp.spec.Judging.Testsets {
+		if test.Name == "tests" {
+			testset = test
+		}
+	}
+	return testset, nil
+}
+
+// importedTestsets returns testsets which are imported with the testset policy
+func (imp PolygonImporter) importedTestsets() []SpecificationTestset {
+	// problem without testsets has no tests to generate or validate
+	main, err := imp.mainTestset()
+	if err != nil {
+		return nil
+	}
+
+	if imp.policy == TestsetPolicyMain {
+		return []SpecificationTestset{main}
+	}
+
+	testsets, err := imp.orderedTestsets()
+	if err != nil {
+		return nil
+	}
+
+	return testsets
+}
+
 func (imp PolygonImporter) GetTestsets() ([]*Group, error) {
+	if len(imp.spec.Judging.Testsets) == 0 {
+		return nil, nil
+	}
 
-	tags := imp.getTags()
+	testsets, err := imp.orderedTestsets()
+	if err != nil {
+		return nil, err
+	}
 
-	blockMin := slices.Contains(tags, "block_min") || slices.Contains(tags, "min_block")
+	switch imp.policy {
+	case TestsetPolicySplit:
+		var groups []*Group
+		for _, testset := range testsets {
+			slot := imp.slots[testset.Name]
+			log.Printf("Importing testset %#v into testsets starting with index %v", testset.Name, slot*TestsetIndexRange)
 
-	var groups []*Group
+			list, err := imp.getTestsetGroups(testset, slot == 0, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, group := range list {
+				if group.Name >= TestsetIndexRange {
+					return nil, fmt.Errorf("group %v of testset %#v does not fit into %v testsets", group.Name, testset.Name, TestsetIndexRange)
+				}
 
-	if len(imp.spec.Judging.Testsets) > 0 {
-		testset := imp.spec.Judging.Testsets[0]
-		for _, test := range imp.spec.Judging.Testsets {
-			if test.Name == "tests" {
-				testset = test
+				group.Name += slot * TestsetIndexRange
+				group.Testset.Index = group.Name
+				for i := range group.Testset.Dependencies {
+					group.Testset.Dependencies[i] += slot * TestsetIndexRange
+				}
 			}
+
+			groups = append(groups, list...)
 		}
+		return groups, nil
+	case TestsetPolicyMerge:
+		var groups []*Group
+		merged := map[uint32]*Group{}
+		seen := map[string]bool{}
+		for i, testset := range testsets {
+			log.Printf("Merging testset %#v", testset.Name)
+
+			// tests which are already merged from previous testsets (like pretests which are also in tests) are skipped
+			skip := map[int]bool{}
+			for gi := range testset.Tests {
+				key := imp.testKey(testset, gi)
+				if seen[key] {
+					skip[gi] = true
+					continue
+				}
+				seen[key] = true
+			}
+
+			if len(skip) > 0 {
+				log.Printf("%v tests of testset %#v are already merged and are skipped", len(skip), testset.Name)
+			}
+
+			list, err := imp.getTestsetGroups(testset, i == 0, skip)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, group := range list {
+				target, ok := merged[group.Name]
+				if !ok {
+					merged[group.Name] = group
+					groups = append(groups, group)
+					continue
+				}
 
-		groupList := testset.Groups
-		if len(groupList) == 0 {
-			groupList = []SpecificationGroup{
-				{FeedbackPolicy: "icpc", Name: "0", Points: 0, PointsPolicy: "each-test"},
-				{FeedbackPolicy: "icpc-expanded", Name: "1", Points: 100, PointsPolicy: "all"},
+				for _, test := range group.Tests {
+					test.Index = int32(len(target.Tests) + 1)
+					target.Tests = append(target.Tests, test)
+				}
 			}
 		}
+		return groups, nil
+	default:
+		main, err := imp.mainTestset()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(imp.spec.Judging.Testsets) > 1 {
+			log.Printf("More than 1 testset defined in problem.xml, only %#v will be imported", main.Name)
+		}
+		return imp.getTestsetGroups(main, true, nil)
+	}
+}
 
-		log.Println(groupList)
+// testKey identifies the test by its input path, or by contents of its input and answer if they can be read, so the
+// same test is recognized in different testsets
+func (imp PolygonImporter) testKey(testset SpecificationTestset, gi int) string {
+	input := filepath.Join(imp.path, fmt.Sprintf(testset.InputPathPattern, gi+1))
 
-		// read tests by group
-		groupTests := map[uint32][]SpecificationTest{}
-		testIndex := map[string]int{}
-		for gi, test := range testset.Tests {
-			groups := strings.Split(test.Group, "-")
-			for _, group := range groups {
-				intName, err := strconv.ParseUint(group, 10, 32)
-				if err != nil {
-					if len(group) == 1 {
-						log.Println("GROUP", group, group[0], uint64(group[0]-'A'))
-						intName = uint64(group[0]-'A') + 1
-					} else if group == "sample" {
+	inputData, err := ioutil.ReadFile(input)
+	if err != nil {
+		return input
+	}
+
+	answerData, err := ioutil.ReadFile(filepath.Join(imp.path, fmt.Sprintf(testset.AnswerPathPattern, gi+1)))
+	if err != nil {
+		return input
+	}
+
+	return TestCacheKey(inputData, answerData)
+}
+
+// getTestsetGroups uploads tests of a single Polygon testset and splits them
+// into groups, examples are only marked when withExamples is set. Tests with
+// indexes in skip are left out.
+func (imp PolygonImporter) getTestsetGroups(testset SpecificationTestset, withExamples bool, skip map[int]bool) ([]*Group, error) {
+
+	tags := imp.getTags()
+
+	blockMin := slices.Contains(tags, "block_min") || slices.Contains(tags, "min_block")
+
+	var groups []*Group
+
+	groupList := testset.Groups
+	if len(groupList) == 0 {
+		groupList = []SpecificationGroup{
+			{FeedbackPolicy: "icpc", Name: "0", Points: 0, PointsPolicy: "each-test"},
+			{FeedbackPolicy: "icpc-expanded", Name: "1", Points: 100, PointsPolicy: "all"},
+		}
+	}
+
+	log.Println(groupList)
+
+	// read tests by group
+	groupTests := map[uint32][]SpecificationTest{}
+	testIndex := map[string]int{}
+	for gi, test := range testset.Tests {
+		if skip[gi] {
+			continue
+		}
+
+		groups := strings.Split(test.Group, "-")
+		for _, group := range groups {
+			intName, err := strconv.ParseUint(group, 10, 32)
+			if err != nil {
+				if len(group) == 1 {
+					log.Println("GROUP", group, group[0], uint64(group[0]-'A'))
+					intName = uint64(group[0]-'A') + 1
+				} else if group == "sample" {
+					intName = 0
+				} else if group == "subtask" {
+					continue
+				} else {
+					if test.Sample {
 						intName = 0
-					} else if group == "subtask" {
-						continue
 					} else {
-						if test.Sample {
-							intName = 0
-						} else {
-							intName = 1
-						}
+						intName = 1
 					}
 				}
-				groupIndex := uint32(intName)
-				groupTests[groupIndex] = append(groupTests[groupIndex], test)
-				testIndex[fmt.Sprint(groupIndex, "/", len(groupTests[groupIndex]))] = gi
 			}
+			groupIndex := uint32(intName)
+			groupTests[groupIndex] = append(groupTests[groupIndex], test)
+			testIndex[fmt.Sprint(groupIndex, "/", len(groupTests[groupIndex]))] = gi
 		}
+	}
 
-		for intName, groupTest := range groupTests {
-			group := groupList[0]
-			found := false
-			for _, g := range groupList {
-				if g.Name == strconv.Itoa(int(intName)) {
-					group = g
-					found = true
-					break
-				}
+	groupNames := make([]uint32, 0, len(groupTests))
+	for intName := range groupTests {
+		groupNames = append(groupNames, intName)
+	}
+
+	sort.Slice(groupNames, func(i, j int) bool { return groupNames[i] < groupNames[j] })
+
+	for _, intName := range groupNames {
+		groupTest := groupTests[intName]
+		group := groupList[0]
+		found := false
+		for _, g := range groupList {
+			if g.Name == strconv.Itoa(int(intName)) {
+				group = g
+				found = true
+				break
 			}
+		}
 
-			if !found {
-				group = SpecificationGroup{
-					FeedbackPolicy: groupList[0].FeedbackPolicy,
-					Name:           string(intName),
-					Points:         0,
-					PointsPolicy:   groupList[0].PointsPolicy,
-					Dependencies:   nil,
-				}
+		if !found {
+			group = SpecificationGroup{
+				FeedbackPolicy: groupList[0].FeedbackPolicy,
+				Name:           strconv.Itoa(int(intName)),
+				Points:         0,
+				PointsPolicy:   groupList[0].PointsPolicy,
+				Dependencies:   nil,
 			}
+		}
 
-			newGroup := new(Group)
-			log.Println(group.Name)
+		newGroup := new(Group)
+		log.Println(group.Name)
 
-			groupIndex := uint32(intName)
+		groupIndex := uint32(intName)
 
-			newGroup.Name = groupIndex
-			xts := &atlas.Testset{}
+		newGroup.Name = groupIndex
+		xts := &atlas.Testset{}
 
-			xts.Index = newGroup.Name
-			xts.TimeLimit = uint32(testset.TimeLimit)
-			xts.MemoryLimit = uint64(testset.MemoryLimit)
-			xts.FileSizeLimit = 536870912
+		xts.Index = newGroup.Name
+		xts.TimeLimit = uint32(testset.TimeLimit)
+		xts.MemoryLimit = uint64(testset.MemoryLimit)
+		xts.FileSizeLimit = 536870912
 
-			xts.ScoringMode = atlas.ScoringMode_EACH
-			if group.PointsPolicy == "complete-group" {
-				xts.ScoringMode = atlas.ScoringMode_ALL
-			}
+		xts.ScoringMode = atlas.ScoringMode_EACH
+		if group.PointsPolicy == "complete-group" {
+			xts.ScoringMode = atlas.ScoringMode_ALL
+		}
 
-			if blockMin && group.Name != "0" {
-				xts.ScoringMode = atlas.ScoringMode_WORST
-			}
+		if blockMin && group.Name != "0" {
+			xts.ScoringMode = atlas.ScoringMode_WORST
+		}
 
-			xts.FeedbackPolicy = atlas.FeedbackPolicy_COMPLETE
-			if group.FeedbackPolicy == "icpc" || group.FeedbackPolicy == "points" || group.FeedbackPolicy == "none" {
-				xts.FeedbackPolicy = atlas.FeedbackPolicy_ICPC
-			} else if group.FeedbackPolicy == "icpc-expanded" {
-				xts.FeedbackPolicy = atlas.FeedbackPolicy_ICPC_EXPANDED
-			}
+		xts.FeedbackPolicy = atlas.FeedbackPolicy_COMPLETE
+		if group.FeedbackPolicy == "icpc" || group.FeedbackPolicy == "points" || group.FeedbackPolicy == "none" {
+			xts.FeedbackPolicy = atlas.FeedbackPolicy_ICPC
+		} else if group.FeedbackPolicy == "icpc-expanded" {
+			xts.FeedbackPolicy = atlas.FeedbackPolicy_ICPC_EXPANDED
+		}
 
-			xts.Dependencies = nil
-			for _, d := range group.Dependencies {
-				intName, err := strconv.ParseUint(d.Group, 10, 32)
-				if err != nil {
-					continue
-				}
-				groupIndex := uint32(intName)
-				xts.Dependencies = append(xts.Dependencies, groupIndex)
+		xts.Dependencies = nil
+		for _, d := range group.Dependencies {
+			intName, err := strconv.ParseUint(d.Group, 10, 32)
+			if err != nil {
+				continue
 			}
+			groupIndex := uint32(intName)
+			xts.Dependencies = append(xts.Dependencies, groupIndex)
+		}
 
-			newGroup.Testset = xts
+		newGroup.Testset = xts
 
-			groupScore := float32(0.0)
-			if blockMin {
-				for _, ts := range groupTest {
-					groupScore = float32(math.Max(float64(groupScore), float64(ts.Points)))
-				}
-			} else {
-				for _, ts := range groupTest {
-					groupScore += ts.Points
-				}
+		groupScore := float32(0.0)
+		if blockMin {
+			for _, ts := range groupTest {
+				groupScore = float32(math.Max(float64(groupScore), float64(ts.Points)))
+			}
+		} else {
+			for _, ts := range groupTest {
+				groupScore += ts.Points
 			}
+		}
 
-			// upload tests
-			for ti, ts := range groupTest {
-				xtt := &atlas.Test{}
+		// upload tests, each test is stored under its own index to keep order
+		newGroup.Tests = make([]*atlas.Test, len(groupTest))
+		err := Parallel(imp.context, len(groupTest), func(ctx context.Context, ti int) error {
+			ts := groupTest[ti]
+			xtt := &atlas.Test{}
 
-				// index in the test list from specification
-				gi := testIndex[fmt.Sprint(xts.Index, "/", int32(ti+1))]
+			// index in the test list from specification
+			gi := testIndex[fmt.Sprint(xts.Index, "/", int32(ti+1))]
 
-				log.Printf("Processing %v test %v (Global Index: %v, ID: %#v) in testset %v (example: %v)", ts.Method, ti, gi, xtt.Id, xts.Index, ts.Sample)
+			log.Printf("Processing %v test %v (Global Index: %v, ID: %#v) in testset %v (example: %v)", ts.Method, ti, gi, xtt.Id, xts.Index, ts.Sample)
 
-				input, err := MakeObject(filepath.Join(imp.path, fmt.Sprintf(testset.InputPathPattern, gi+1)), imp.kpr)
-				if err != nil {
-					log.Printf("Unable to upload test input data to E-Olymp: %v", err)
-					return nil, err
-				}
+			input, err := MakeObject(filepath.Join(imp.path, fmt.Sprintf(testset.InputPathPattern, gi+1)), imp.kpr)
+			if err != nil {
+				log.Printf("Unable to upload test input data to E-Olymp: %v", err)
+				return err
+			}
 
-				answer, err := MakeObject(filepath.Join(imp.path, fmt.Sprintf(testset.AnswerPathPattern, gi+1)), imp.kpr)
-				if err != nil {
-					log.Printf("Unable to upload test answer data to E-Olymp: %v", err)
-					return nil, err
-				}
+			answer, err := MakeObject(filepath.Join(imp.path, fmt.Sprintf(testset.AnswerPathPattern, gi+1)), imp.kpr)
+			if err != nil {
+				log.Printf("Unable to upload test answer data to E-Olymp: %v", err)
+				return err
+			}
 
-				xtt.Index = int32(ti + 1)
-				xtt.Example = (intName == 0) && (!imp.AreExamplesOverwritten())
-				if blockMin {
-					xtt.Score = groupScore
-				} else {
-					xtt.Score = ts.Points
-				}
-				xtt.InputObjectId = input
-				xtt.AnswerObjectId = answer
+			xtt.Index = int32(ti + 1)
+			xtt.Example = withExamples && (intName == 0) && (!imp.AreExamplesOverwritten())
+			if blockMin {
+				xtt.Score = groupScore
+			} else {
+				xtt.Score = ts.Points
+			}
+			xtt.InputObjectId = input
+			xtt.AnswerObjectId = answer
 
-				if xts.FeedbackPolicy == atlas.FeedbackPolicy_ICPC_EXPANDED {
-					score := 100 / len(groupTests[groupIndex])
-					if len(groupTests[groupIndex])-ti <= 100%len(groupTests[groupIndex]) {
-						score++
-					}
-					xtt.Score = float32(score)
+			if xts.FeedbackPolicy == atlas.FeedbackPolicy_ICPC_EXPANDED {
+				score := 100 / len(groupTests[groupIndex])
+				if len(groupTests[groupIndex])-ti <= 100%len(groupTests[groupIndex]) {
+					score++
 				}
-
-				newGroup.Tests = append(newGroup.Tests, xtt)
+				xtt.Score = float32(score)
 			}
-			groups = append(groups, newGroup)
 
+			newGroup.Tests[ti] = xtt
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 
+		groups = append(groups, newGroup)
+
 	}
+
 	return groups, nil
 }
 
@@ -479,40 +732,23 @@ func (imp PolygonImporter) GetTemplates(pid *string) ([]*atlas.Template, error)
 		}
 	}
 
-	if len(imp.spec.Graders) > 0 {
-		template := &atlas.Template{}
-		template.ProblemId = *pid
-		template.Runtime = "cpp:17-gnu10"
-		for _, file := range imp.spec.Graders {
-			path := filepath.Join(imp.path, file.Path)
-			hasSolution := false
-			for _, asset := range file.Assets {
-				if asset.Name == "solution" {
-					hasSolution = true
-				}
-			}
-			if hasSolution {
-				obj, err := MakeObjectGetFile(path, imp.kpr)
-				if err != nil {
-					fmt.Println("Failed to upload grader")
-					return nil, err
-				}
-				obj = "ern:blob:" + obj
-				splits := strings.Split(path, "/")
-				fileName := splits[len(splits)-1]
-				f := atlas.File{
-					Path:      fileName,
-					SourceErn: obj, //obj.BlobErn, // TODO FIX IT
-				}
-
-				log.Println(fileName, "has been uploaded")
-				template.Files = append(template.Files, &f)
+	var graders []string
+	for _, file := range imp.spec.Graders {
+		for _, asset := range file.Assets {
+			if asset.Name == "solution" {
+				graders = append(graders, filepath.Join(imp.path, file.Path))
+				break
 			}
 		}
-		if len(template.Files) > 0 {
-			templates = append(templates, template)
-		}
+	}
+
+	template, err := MakeGraderTemplate(*pid, "cpp:17-gnu10", graders, imp.kpr)
+	if err != nil {
+		return nil, err
+	}
 
+	if template != nil {
+		templates = append(templates, template)
 	}
 
 	return templates, nil
@@ -540,19 +776,11 @@ func (imp PolygonImporter) GetAttachments(pid *string) ([]*atlas.Attachment, err
 			splits := strings.Split(material.Path, "/")
 			fileName := splits[len(splits)-1]
 
-			asset, err := imp.ts.UploadAsset(imp.context, &typewriter.UploadAssetInput{Filename: fileName, Data: data})
-			if err != nil {
-				log.Println(err)
-				return nil, err
-			}
-
 			attachment := atlas.Attachment{
 				ProblemId: *pid,
 				Name:      fileName,
-				Link:      asset.Link,
+				Link:      UploadAsset(imp.ts, fileName, data),
 			}
-
-			log.Println(fileName, "has been uploaded")
 			attachments = append(attachments, &attachment)
 		}
 	}
@@ -562,3 +790,392 @@ func (imp PolygonImporter) GetAttachments(pid *string) ([]*atlas.Attachment, err
 func (imp PolygonImporter) AreExamplesOverwritten() bool {
 	return imp.HasInteractor()
 }
+
+// GenerateTests creates missing inputs of generated tests by running their commands with generators from files/, and
+// missing answers with the main solution. Results are kept in the cache, so repeated imports do not run them again.
+func (imp PolygonImporter) GenerateTests(compiler *runner.Compiler, cache *TestCache) error {
+	var inputs, answers []LocalTest
+	commands := map[string]string{}
+
+	for _, testset := range imp.importedTestsets() {
+		for i, test := range testset.Tests {
+			local := LocalTest{
+				Testset: testset.Name,
+				Index:   i + 1,
+				Input:   filepath.Join(imp.path, fmt.Sprintf(testset.InputPathPattern, i+1)),
+				Answer:  filepath.Join(imp.path, fmt.Sprintf(testset.AnswerPathPattern, i+1)),
+			}
+
+			if _, err := os.Stat(local.Input); os.IsNotExist(err) {
+				if test.Method != "generated" || test.Command == "" {
+					return fmt.Errorf("input of test %v of testset %#v is missing", local.Index, local.Testset)
+				}
+
+				inputs = append(inputs, local)
+				commands[local.Input] = test.Command
+			}
+
+			if _, err := os.Stat(local.Answer); os.IsNotExist(err) {
+				answers = append(answers, local)
+			}
+		}
+	}
+
+	if len(inputs) == 0 && len(answers) == 0 {
+		return nil
+	}
+
+	dir, err := ioutil.TempDir("", "polyglot-generator-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	includes := filepath.Join(imp.path, "files")
+
+	type generator struct {
+		path    string
+		source  []byte
+		program *LocalProgram
+	}
+
+	generators := map[string]*generator{}
+	keys := map[string]string{}
+
+	var pending []LocalTest
+	for _, test := range inputs {
+		command := commands[test.Input]
+		name := strings.Fields(command)[0]
+
+		gen, ok := generators[name]
+		if !ok {
+			path, ok := imp.generatorSource(name)
+			if !ok {
+				return fmt.Errorf("generator %#v is not found in the package", name)
+			}
+
+			source, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			gen = &generator{path: path, source: source}
+			generators[name] = gen
+		}
+
+		if err := os.MkdirAll(filepath.Dir(test.Input), 0755); err != nil {
+			return err
+		}
+
+		keys[test.Input] = TestCacheKey(gen.source, []byte(command))
+		if !cache.Get(keys[test.Input], test.Input) {
+			pending = append(pending, test)
+		}
+	}
+
+	// generators are compiled once and only if some of their tests are not cached
+	for _, test := range pending {
+		gen := generators[strings.Fields(commands[test.Input])[0]]
+		if gen.program != nil {
+			continue
+		}
+
+		if gen.program, err = PrepareProgram(imp.context, compiler, dir, gen.path, includes); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Generating %v tests, %v of them are cached", len(inputs), len(inputs)-len(pending))
+
+	err = Parallel(imp.context, len(pending), func(ctx context.Context, i int) error {
+		test := pending[i]
+		args := strings.Fields(commands[test.Input])
+		gen := generators[args[0]]
+
+		result, err := runner.Run(ctx, gen.program.Command, append(append([]string{}, gen.program.Args...), args[1:]...), nil, 0, 0)
+		if err != nil {
+			return fmt.Errorf("unable to generate test %v of testset %#v: %w", test.Index, test.Testset, err)
+		}
+
+		if result.ExitCode != 0 {
+			return fmt.Errorf("generator %#v has failed on test %v of testset %#v: %v", commands[test.Input], test.Index, test.Testset, result.Message())
+		}
+
+		if err := ioutil.WriteFile(test.Input, result.Stdout, 0644); err != nil {
+			return err
+		}
+
+		cache.Put(keys[test.Input], result.Stdout)
+
+		return nil
+	})
+
+	if err != nil || len(answers) == 0 {
+		return err
+	}
+
+	var main *LocalSolution
+	for _, solution := range imp.LocalSolutions() {
+		if solution.Tag == "main" {
+			main = &solution
+			break
+		}
+	}
+
+	if main == nil {
+		return errors.New("answers can not be generated, the package has no main solution")
+	}
+
+	source, err := ioutil.ReadFile(main.Path)
+	if err != nil {
+		return err
+	}
+
+	var inputData [][]byte
+	pending = nil
+	for _, test := range answers {
+		input, err := ioutil.ReadFile(test.Input)
+		if err != nil {
+			return err
+		}
+
+		keys[test.Answer] = TestCacheKey(source, input)
+		if !cache.Get(keys[test.Answer], test.Answer) {
+			pending = append(pending, test)
+			inputData = append(inputData, input)
+		}
+	}
+
+	log.Printf("Generating %v answers with %v, %v of them are cached", len(answers), filepath.Base(main.Path), len(answers)-len(pending))
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	program, err := PrepareProgram(imp.context, compiler, dir, main.Path, includes)
+	if err != nil {
+		return err
+	}
+
+	return Parallel(imp.context, len(pending), func(ctx context.Context, i int) error {
+		test := pending[i]
+
+		result, err := runner.Run(ctx, program.Command, program.Args, bytes.NewReader(inputData[i]), 0, 0)
+		if err != nil {
+			return fmt.Errorf("unable to generate answer of test %v of testset %#v: %w", test.Index, test.Testset, err)
+		}
+
+		if result.ExitCode != 0 {
+			return fmt.Errorf("main solution has failed on test %v of testset %#v: %v", test.Index, test.Testset, result.Message())
+		}
+
+		if err := ioutil.WriteFile(test.Answer, result.Stdout, 0644); err != nil {
+			return err
+		}
+
+		cache.Put(keys[test.Answer], result.Stdout)
+
+		return nil
+	})
+}
+
+// generatorSource finds source of the generator among executables of the package or in files/
+func (imp PolygonImporter) generatorSource(name string) (string, bool) {
+	for _, executable := range imp.spec.Templates {
+		path := executable.Source.Path
+		if strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) != name {
+			continue
+		}
+
+		if _, ok := LanguageByExtension(path); ok {
+			return filepath.Join(imp.path, path), true
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(imp.path, "files", name+".*"))
+	for _, path := range matches {
+		if _, ok := LanguageByExtension(path); ok {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// Validate compiles the validator of the package and runs it on inputs of the testsets which are imported. Nothing is
+// uploaded if any of the tests is rejected by the validator.
+func (imp PolygonImporter) Validate(compiler *runner.Compiler) error {
+	source, ok := imp.validatorSource()
+	if !ok {
+		log.Println("Problem has no C++ validator, tests are not validated")
+		return nil
+	}
+
+	dir, err := ioutil.TempDir("", "polyglot-validator-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	validator := filepath.Join(dir, "validator")
+
+	log.Printf("Compiling validator %v", source)
+	if err := compiler.Compile(imp.context, filepath.Join(imp.path, source), validator, filepath.Join(imp.path, "files")); err != nil {
+		return err
+	}
+
+	type validation struct {
+		testset string
+		index   int
+		test    SpecificationTest
+		input   string
+	}
+
+	var tests []validation
+	for _, testset := range imp.importedTestsets() {
+		for i, test := range testset.Tests {
+			tests = append(tests, validation{
+				testset: testset.Name,
+				index:   i + 1,
+				test:    test,
+				input:   filepath.Join(imp.path, fmt.Sprintf(testset.InputPathPattern, i+1)),
+			})
+		}
+	}
+
+	log.Printf("Validating %v tests", len(tests))
+
+	return Parallel(imp.context, len(tests), func(ctx context.Context, i int) error {
+		test := tests[i]
+
+		input, err := os.Open(test.input)
+		if os.IsNotExist(err) {
+			log.Printf("Test %v of testset %#v is not in the package, it is not validated", test.index, test.testset)
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = input.Close()
+		}()
+
+		args := []string{"--testset", test.testset}
+		if test.test.Group != "" {
+			args = append(args, "--group", test.test.Group)
+		}
+
+		result, err := runner.Run(ctx, validator, args, input, 0, 0)
+		if err != nil {
+			return fmt.Errorf("unable to validate test %v of testset %#v: %w", test.index, test.testset, err)
+		}
+
+		if result.ExitCode != 0 {
+			return fmt.Errorf("test %v of testset %#v is invalid: %v", test.index, test.testset, result.Message())
+		}
+
+		return nil
+	})
+}
+
+// validatorSource returns path of the C++ source of the validator
+func (imp PolygonImporter) validatorSource() (string, bool) {
+	for _, validator := range imp.spec.Validators {
+		if source, ok := SourceByType(validator.Sources, mapping["cpp:17-gnu10"]...); ok {
+			return source.Path, true
+		}
+
+		for _, source := range validator.Sources {
+			if strings.EqualFold(filepath.Ext(source.Path), ".cpp") {
+				return source.Path, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// LocalTests returns tests of the imported testsets with their time and memory limits
+func (imp PolygonImporter) LocalTests() []LocalTest {
+	var tests []LocalTest
+	for _, testset := range imp.importedTestsets() {
+		for i := range testset.Tests {
+			tests = append(tests, LocalTest{
+				Testset:     testset.Name,
+				Index:       i + 1,
+				Input:       filepath.Join(imp.path, fmt.Sprintf(testset.InputPathPattern, i+1)),
+				Answer:      filepath.Join(imp.path, fmt.Sprintf(testset.AnswerPathPattern, i+1)),
+				TimeLimit:   time.Duration(testset.TimeLimit) * time.Millisecond,
+				MemoryLimit: uint64(testset.MemoryLimit),
+			})
+		}
+	}
+	return tests
+}
+
+// LocalSolutions returns reference solutions of the package, the main solution goes first
+func (imp PolygonImporter) LocalSolutions() []LocalSolution {
+	var solutions []LocalSolution
+	for _, reference := range imp.spec.References {
+		for _, source := range reference.Sources {
+			solution := LocalSolution{Path: filepath.Join(imp.path, source.Path), Tag: reference.Tag}
+			if reference.Tag == "main" {
+				solutions = append([]LocalSolution{solution}, solutions...)
+			} else {
+				solutions = append(solutions, solution)
+			}
+		}
+	}
+	return solutions
+}
+
+// PolygonTagMatches checks that verdicts of the solution on all tests are expected for its Polygon tag
+func PolygonTagMatches(tag string, verdicts map[Verdict]int) (bool, error) {
+	// only checks that every failed test has one of the verdicts, want requires at least one of them
+	only := func(want bool, allowed ...Verdict) bool {
+		failed := 0
+		for verdict, count := range verdicts {
+			if verdict == VerdictAccepted {
+				continue
+			}
+
+			if !slices.Contains(allowed, verdict) {
+				return false
+			}
+
+			failed += count
+		}
+		return !want || failed > 0
+	}
+
+	switch tag {
+	case "main", "accepted":
+		return only(false), nil
+	case "rejected":
+		return !only(false), nil
+	case "wrong-answer":
+		return only(true, VerdictWrongAnswer), nil
+	case "presentation-error":
+		return only(true, VerdictPresentationError), nil
+	case "time-limit-exceeded":
+		return only(true, VerdictTimeLimit), nil
+	case "time-limit-exceeded-or-accepted":
+		return only(false, VerdictTimeLimit), nil
+	case "time-limit-exceeded-or-memory-limit-exceeded":
+		return only(true, VerdictTimeLimit, VerdictMemoryLimit), nil
+	case "memory-limit-exceeded":
+		return only(true, VerdictMemoryLimit), nil
+	case "failed":
+		return only(true, VerdictRuntimeError), nil
+	default:
+		return false, fmt.Errorf("unknown solution tag %#v", tag)
+	}
+}
@@ -0,0 +1,70 @@
+package types_test
+
+import (
+	"context"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"testing"
+)
+
+func TestPolygonTagMatches(t *testing.T) {
+	tt := []struct {
+		tag      string
+		verdicts map[types.Verdict]int
+		matches  bool
+	}{
+		{tag: "main", verdicts: map[types.Verdict]int{types.VerdictAccepted: 3}, matches: true},
+		{tag: "main", verdicts: map[types.Verdict]int{types.VerdictCompilationError: 1}, matches: false},
+		{tag: "rejected", verdicts: map[types.Verdict]int{types.VerdictCompilationError: 1}, matches: true},
+		{tag: "rejected", verdicts: map[types.Verdict]int{types.VerdictAccepted: 3}, matches: false},
+		{tag: "wrong-answer", verdicts: map[types.Verdict]int{types.VerdictAccepted: 2, types.VerdictWrongAnswer: 1}, matches: true},
+		{tag: "wrong-answer", verdicts: map[types.Verdict]int{types.VerdictCompilationError: 1}, matches: false},
+		{tag: "time-limit-exceeded-or-accepted", verdicts: map[types.Verdict]int{types.VerdictAccepted: 3}, matches: true},
+		{tag: "time-limit-exceeded-or-memory-limit-exceeded", verdicts: map[types.Verdict]int{types.VerdictMemoryLimit: 1}, matches: true},
+		{tag: "failed", verdicts: map[types.Verdict]int{types.VerdictRuntimeError: 1}, matches: true},
+	}
+
+	for _, tc := range tt {
+		matches, err := types.PolygonTagMatches(tc.tag, tc.verdicts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if matches != tc.matches {
+			t.Errorf("Tag %#v with verdicts %v: got %v, expected %v", tc.tag, tc.verdicts, matches, tc.matches)
+		}
+	}
+
+	if _, err := types.PolygonTagMatches("do-not-run", nil); err == nil {
+		t.Error("Unknown tag must be reported")
+	}
+}
+
+func TestPolygonWithoutTestsets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"problem.xml": `<?xml version="1.0" encoding="utf-8"?><problem revision="1" short-name="draft"><judging></judging></problem>`,
+	})
+
+	for _, policy := range []string{types.TestsetPolicyMain, types.TestsetPolicySplit, types.TestsetPolicyMerge} {
+		imp, err := types.CreatePolygonImporter(dir, context.Background(), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := imp.SetTestsetPolicy(policy, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := imp.TestsetSlots(); err == nil {
+			t.Errorf("Expected an error for testset slots with %v policy", policy)
+		}
+
+		if groups, err := imp.GetTestsets(); err != nil || len(groups) != 0 {
+			t.Errorf("Expected no testsets with %v policy, got %v (%v)", policy, groups, err)
+		}
+
+		if tests := imp.LocalTests(); len(tests) != 0 {
+			t.Errorf("Expected no local tests with %v policy, got %v", policy, tests)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package types
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ServeConfig is serve.cfg of ejudge contest. Parameters before the first section (or in [global]) are global,
+// other sections are kept in the order of the file.
+type ServeConfig struct {
+	Global   *ServeSection
+	Sections []*ServeSection
+}
+
+// ServeSection is a section of serve.cfg, parameters without value (like abstract) are stored as "1"
+type ServeSection struct {
+	Name   string
+	Params map[string]string
+}
+
+// ServeProblem is [problem] section with its abstract problems and global section, parameters are looked up in this
+// order
+type ServeProblem struct {
+	chain []*ServeSection
+}
+
+// serveOwnParams are not inherited from abstract problems
+var serveOwnParams = map[string]bool{
+	"abstract":      true,
+	"id":            true,
+	"internal_name": true,
+	"long_name":     true,
+	"short_name":    true,
+	"super":         true,
+}
+
+// ReadServeConfig parses serve.cfg
+func ReadServeConfig(path string) (*ServeConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("Unable to open %v: %v", path, err)
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	config := &ServeConfig{Global: &ServeSection{Name: "global", Params: map[string]string{}}}
+	section := config.Global
+
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(strings.TrimSuffix(scanner.Text(), "\r"))
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		// preprocessor directives (@if, @include) are not supported
+		if line[0] == '@' {
+			log.Printf("Directive %#v in line %v of %v is ignored", line, number, path)
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%v:%v: section name is not closed", path, number)
+			}
+
+			name := strings.ToLower(strings.TrimSpace(line[1:end]))
+			if name == "global" {
+				section = config.Global
+				continue
+			}
+
+			section = &ServeSection{Name: name, Params: map[string]string{}}
+			config.Sections = append(config.Sections, section)
+			continue
+		}
+
+		key, value, err := parseServeParam(line)
+		if err != nil {
+			return nil, fmt.Errorf("%v:%v: %w", path, number, err)
+		}
+
+		section.Params[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// parseServeParam parses "key = value", "key=value", `key = "quoted value"` or a single "key"
+func parseServeParam(line string) (string, string, error) {
+	eq := strings.Index(line, "=")
+	if eq < 0 {
+		key := strings.TrimSpace(strings.SplitN(line, "#", 2)[0])
+		if strings.ContainsAny(key, " \t") {
+			return "", "", fmt.Errorf("invalid parameter %#v", line)
+		}
+		return key, "1", nil
+	}
+
+	key := strings.TrimSpace(line[:eq])
+	value := strings.TrimSpace(line[eq+1:])
+	if key == "" {
+		return "", "", fmt.Errorf("invalid parameter %#v", line)
+	}
+
+	if !strings.HasPrefix(value, "\"") {
+		return key, strings.TrimSpace(strings.SplitN(value, "#", 2)[0]), nil
+	}
+
+	var sb strings.Builder
+	for i := 1; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"':
+			return key, sb.String(), nil
+		case '\\':
+			if i+1 == len(value) {
+				return "", "", fmt.Errorf("invalid escape in %#v", line)
+			}
+			i++
+			switch value[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(value[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", "", fmt.Errorf("quoted value is not closed in %#v", line)
+}
+
+// Problems returns [problem] sections which are not abstract
+func (c *ServeConfig) Problems() []*ServeProblem {
+	var problems []*ServeProblem
+	for _, section := range c.Sections {
+		if section.Name != "problem" || section.Params["abstract"] == "1" {
+			continue
+		}
+
+		problem, err := c.resolve(section)
+		if err != nil {
+			log.Printf("Problem %#v is skipped: %v", section.Params["short_name"], err)
+			continue
+		}
+
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// Problem finds problem by short name or internal name
+func (c *ServeConfig) Problem(name string) (*ServeProblem, error) {
+	for _, section := range c.Sections {
+		if section.Name != "problem" || section.Params["abstract"] == "1" {
+			continue
+		}
+
+		if section.Params["short_name"] == name || section.Params["internal_name"] == name {
+			return c.resolve(section)
+		}
+	}
+
+	return nil, fmt.Errorf("problem %#v is not found in serve.cfg", name)
+}
+
+// abstract returns abstract problem by short name
+func (c *ServeConfig) abstract(name string) *ServeSection {
+	for _, section := range c.Sections {
+		if section.Name == "problem" && section.Params["abstract"] == "1" && section.Params["short_name"] == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// resolve follows super of the problem through all levels of abstract problems
+func (c *ServeConfig) resolve(section *ServeSection) (*ServeProblem, error) {
+	problem := &ServeProblem{chain: []*ServeSection{section}}
+	seen := map[*ServeSection]bool{section: true}
+
+	for current := section; current.Params["super"] != ""; {
+		super := c.abstract(current.Params["super"])
+		if super == nil {
+			return nil, fmt.Errorf("abstract problem %#v is not found", current.Params["super"])
+		}
+
+		if seen[super] {
+			return nil, fmt.Errorf("abstract problem %#v refers to itself", current.Params["super"])
+		}
+
+		seen[super] = true
+		problem.chain = append(problem.chain, super)
+		current = super
+	}
+
+	problem.chain = append(problem.chain, c.Global)
+
+	return problem, nil
+}
+
+// Get returns parameter of the problem, its abstract problems or global section
+func (p *ServeProblem) Get(key string) (string, bool) {
+	for i, section := range p.chain {
+		if i > 0 && serveOwnParams[key] {
+			break
+		}
+
+		if value, ok := section.Params[key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// String returns parameter or fallback if it is not set
+func (p *ServeProblem) String(key, fallback string) string {
+	if value, ok := p.Get(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// Int returns integer parameter or fallback if it is not set
+func (p *ServeProblem) Int(key string, fallback int) (int, error) {
+	value, ok := p.Get(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %v %#v: %w", key, value, err)
+	}
+
+	return number, nil
+}
+
+// Bool checks if the flag is set
+func (p *ServeProblem) Bool(key string) bool {
+	value, _ := p.Get(key)
+	return value != "" && value != "0" && value != "no" && value != "false"
+}
+
+func (p *ServeProblem) ShortName() string {
+	return p.String("short_name", "")
+}
+
+func (p *ServeProblem) InternalName() string {
+	return p.String("internal_name", p.ShortName())
+}
+
+func (p *ServeProblem) LongName() string {
+	return p.String("long_name", "")
+}
+
+// TimeLimit returns time limit in milliseconds from time_limit_millis or time_limit (in seconds), 0 means not set
+func (p *ServeProblem) TimeLimit() (uint32, error) {
+	if millis, err := p.Int("time_limit_millis", 0); err != nil || millis > 0 {
+		return uint32(millis), err
+	}
+
+	value, ok := p.Get("time_limit")
+	if !ok || value == "" {
+		return 0, nil
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time_limit %#v: %w", value, err)
+	}
+
+	return uint32(seconds * 1000), nil
+}
+
+// MaxVmSize returns memory limit in bytes, 0 means not set
+func (p *ServeProblem) MaxVmSize() (uint64, error) {
+	return p.Size("max_vm_size")
+}
+
+// MaxStackSize returns stack limit in bytes, 0 means not set
+func (p *ServeProblem) MaxStackSize() (uint64, error) {
+	return p.Size("max_stack_size")
+}
+
+// Size parses size parameter like 256M, 65536K or 1G into bytes, 0 means not set
+func (p *ServeProblem) Size(key string) (uint64, error) {
+	value, ok := p.Get(key)
+	if !ok || value == "" {
+		return 0, nil
+	}
+
+	multiplier := uint64(1)
+	switch strings.ToUpper(value[len(value)-1:]) {
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	}
+
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+
+	size, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %v %#v: %w", key, value, err)
+	}
+
+	return size * multiplier, nil
+}
+
+// TestPattern returns printf pattern of test files, test_sfx is appended to the test number if test_pat is not set
+func (p *ServeProblem) TestPattern() string {
+	if pattern := p.String("test_pat", ""); pattern != "" {
+		return pattern
+	}
+	return "%03d" + p.String("test_sfx", "")
+}
+
+// CorrPattern returns printf pattern of answer files, corr_sfx is appended to the test number if corr_pat is not
+// set
+func (p *ServeProblem) CorrPattern() string {
+	if pattern := p.String("corr_pat", ""); pattern != "" {
+		return pattern
+	}
+	return "%03d" + p.String("corr_sfx", "")
+}
+
+func (p *ServeProblem) CheckCmd() string {
+	return p.String("check_cmd", "")
+}
+
+func (p *ServeProblem) StandardChecker() string {
+	return p.String("standard_checker", "")
+}
+
+// TestScoreList returns scores of tests from test_score_list, "[N]" sets the number of the next test and skipped
+// tests get test_score (1 by default). Nil is returned if test_score_list is not set.
+func (p *ServeProblem) TestScoreList() ([]int, error) {
+	value, ok := p.Get("test_score_list")
+	if !ok {
+		return nil, nil
+	}
+
+	fallback, err := p.Int("test_score", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := []int{}
+	for _, item := range strings.Fields(value) {
+		if strings.HasPrefix(item, "[") {
+			end := strings.Index(item, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid test_score_list item %#v", item)
+			}
+
+			number, err := strconv.Atoi(item[1:end])
+			if err != nil || number < 1 {
+				return nil, fmt.Errorf("invalid test_score_list item %#v", item)
+			}
+
+			for len(scores) < number-1 {
+				scores = append(scores, fallback)
+			}
+
+			if item = item[end+1:]; item == "" {
+				continue
+			}
+		}
+
+		score, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid test_score_list item %#v", item)
+		}
+
+		scores = append(scores, score)
+	}
+
+	return scores, nil
+}
@@ -0,0 +1,144 @@
+package types_test
+
+import (
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readServeConfig(t *testing.T, content string) (*types.ServeConfig, error) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"serve.cfg": content})
+	return types.ReadServeConfig(filepath.Join(dir, "serve.cfg"))
+}
+
+func TestReadServeConfigParams(t *testing.T) {
+	tt := []struct {
+		name  string
+		line  string
+		key   string
+		value string
+	}{
+		{name: "spaces", line: "time_limit = 2", key: "time_limit", value: "2"},
+		{name: "no spaces", line: "time_limit=2", key: "time_limit", value: "2"},
+		{name: "comment", line: "check_cmd = check # the checker", key: "check_cmd", value: "check"},
+		{name: "quoted", line: `long_name = "A + B # sum"`, key: "long_name", value: "A + B # sum"},
+		{name: "escapes", line: `checker_env = "EPS=\"1e-6\"\tX"`, key: "checker_env", value: "EPS=\"1e-6\"\tX"},
+		{name: "flag", line: "use_stdin", key: "use_stdin", value: "1"},
+		{name: "flag with comment", line: "use_stdout # always", key: "use_stdout", value: "1"},
+		{name: "crlf", line: "short_name = A\r", key: "short_name", value: "A"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := readServeConfig(t, "[problem]\n"+tc.line+"\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(config.Sections) != 1 {
+				t.Fatalf("Expected a single section, got %v", len(config.Sections))
+			}
+
+			if value, ok := config.Sections[0].Params[tc.key]; !ok || value != tc.value {
+				t.Errorf("Parameter %v is %#v, expected %#v", tc.key, value, tc.value)
+			}
+		})
+	}
+}
+
+func TestReadServeConfigErrors(t *testing.T) {
+	for _, content := range []string{
+		"[problem\n",
+		"[problem]\nlong_name = \"not closed\n",
+		"[problem]\ntwo words\n",
+		"[problem]\n= value\n",
+	} {
+		if _, err := readServeConfig(t, content); err == nil {
+			t.Errorf("Expected error for %#v", content)
+		}
+	}
+}
+
+const serveConfig = "# contest settings\r\n" +
+	"contest_time = 300\r\n" +
+	"time_limit = 1\r\n" +
+	"\r\n" +
+	"[language]\r\n" +
+	"short_name = gcc\r\n" +
+	"\r\n" +
+	"[global]\r\n" +
+	"max_vm_size = 256M\r\n" +
+	"\r\n" +
+	"[problem]\r\n" +
+	"abstract\r\n" +
+	"short_name = Generic\r\n" +
+	"test_sfx = .dat\r\n" +
+	"corr_sfx = .ans\r\n" +
+	"standard_checker = cmp_file\r\n" +
+	"\r\n" +
+	"[problem]\r\n" +
+	"abstract\r\n" +
+	"short_name = Scored\r\n" +
+	"super = Generic\r\n" +
+	"test_score = 2\r\n" +
+	"\r\n" +
+	"[problem]\r\n" +
+	"id = 1\r\n" +
+	"short_name = A\r\n" +
+	"internal_name = sum\r\n" +
+	"super = Scored\r\n" +
+	"test_score_list = 1 [4] 5 6 [7]7\r\n" +
+	"\r\n" +
+	"[problem]\r\n" +
+	"short_name = B\r\n" +
+	"super = Missing\r\n"
+
+func TestServeConfigInheritance(t *testing.T) {
+	config, err := readServeConfig(t, serveConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// B refers to unknown abstract problem and is skipped
+	problems := config.Problems()
+	if len(problems) != 1 || problems[0].ShortName() != "A" {
+		t.Fatalf("Expected only problem A, got %v problems", len(problems))
+	}
+
+	problem, err := config.Problem("sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := problem.TestPattern(); got != "%03d.dat" {
+		t.Errorf("Test pattern from two levels of super is %#v, expected \"%%03d.dat\"", got)
+	}
+
+	if got := problem.StandardChecker(); got != "cmp_file" {
+		t.Errorf("Standard checker is %#v, expected \"cmp_file\"", got)
+	}
+
+	if got, err := problem.MaxVmSize(); err != nil || got != 256<<20 {
+		t.Errorf("Memory limit from [global] is %v (%v), expected %v", got, err, 256<<20)
+	}
+
+	if got, err := problem.TimeLimit(); err != nil || got != 1000 {
+		t.Errorf("Time limit from parameters before the first section is %v (%v), expected 1000", got, err)
+	}
+
+	if problem.Bool("abstract") {
+		t.Error("Abstract flag must not be inherited")
+	}
+
+	scores, err := problem.TestScoreList()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// [N] skips to test N, skipped tests get test_score of the abstract problem
+	if want := []int{1, 2, 2, 5, 6, 2, 7}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("Test scores are %v, expected %v", scores, want)
+	}
+}
@@ -1,6 +1,7 @@
 package types
 
 type Specification struct {
+	Revision   int                      `xml:"revision,attr"`
 	Names      []SpecificationName      `xml:"names>name"`
 	Statements []SpecificationStatement `xml:"statements>statement"`
 	Solutions  []SpecificationSolution  `xml:"tutorials>tutorial"`
@@ -10,6 +11,8 @@ type Specification struct {
 	Judging    SpecificationJudging     `xml:"judging"`
 	Checker    SpecificationChecker     `xml:"assets>checker"`
 	Interactor SpecificationInteractor  `xml:"assets>interactor"`
+	Validators []SpecificationValidator `xml:"assets>validators>validator"`
+	References []SpecificationReference `xml:"assets>solutions>solution"`
 	Tags       []SpecificationTag       `xml:"tags>tag"`
 }
 
@@ -102,6 +105,17 @@ type SpecificationInteractor struct {
 	Binaries []SpecificationBinary `xml:"binary"`
 }
 
+// SpecificationReference is a reference solution, tag is the expected outcome (main, accepted, wrong-answer and so on)
+type SpecificationReference struct {
+	Tag     string                `xml:"tag,attr"`
+	Sources []SpecificationSource `xml:"source"`
+}
+
+type SpecificationValidator struct {
+	Sources  []SpecificationSource `xml:"source"`
+	Binaries []SpecificationBinary `xml:"binary"`
+}
+
 type SpecificationBinary struct {
 	Path string `xml:"path,attr"`
 	Type string `xml:"type,attr"`
@@ -116,6 +130,57 @@ type SpecificationTag struct {
 	Value string `xml:"value,attr"`
 }
 
+// Pcms2Specification is problem.xml of PCMS2 (NEERC) problem
+type Pcms2Specification struct {
+	Id        string       `xml:"id,attr"`
+	ShortName string       `xml:"short-name,attr"`
+	Name      string       `xml:"name,attr"`
+	Judging   Pcms2Judging `xml:"judging"`
+}
+
+type Pcms2Judging struct {
+	InputFile  string         `xml:"input-file,attr"`
+	OutputFile string         `xml:"output-file,attr"`
+	Script     Pcms2Script    `xml:"script"`
+	Testsets   []Pcms2Testset `xml:"testset"`
+	Verifier   Pcms2Program   `xml:"verifier"`
+	Interactor Pcms2Program   `xml:"interactor"`
+}
+
+type Pcms2Script struct {
+	Type       string         `xml:"type,attr"`
+	Testsets   []Pcms2Testset `xml:"testset"`
+	Verifier   Pcms2Program   `xml:"verifier"`
+	Interactor Pcms2Program   `xml:"interactor"`
+}
+
+type Pcms2Testset struct {
+	TestCount   int         `xml:"test-count,attr"`
+	InputHref   string      `xml:"input-href,attr"`
+	AnswerHref  string      `xml:"answer-href,attr"`
+	InputName   string      `xml:"input-name,attr"`
+	OutputName  string      `xml:"output-name,attr"`
+	TimeLimit   string      `xml:"time-limit,attr"`
+	MemoryLimit string      `xml:"memory-limit,attr"`
+	Tests       []Pcms2Test `xml:"test"`
+}
+
+type Pcms2Test struct {
+	Points float32 `xml:"points,attr"`
+	Group  string  `xml:"group,attr"`
+}
+
+type Pcms2Program struct {
+	Type     string                `xml:"type,attr"`
+	Binaries []Pcms2Binary         `xml:"binary"`
+	Sources  []SpecificationSource `xml:"source"`
+}
+
+type Pcms2Binary struct {
+	ExecutableId string `xml:"executable-id,attr"`
+	File         string `xml:"file,attr"`
+}
+
 type PolygonProblemProperties struct {
 	Language    string `json:"language"`
 	Name        string `json:"name"`
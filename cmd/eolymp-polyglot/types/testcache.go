@@ -0,0 +1,74 @@
+package types
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// TestCache keeps generated inputs and answers on the disk, files are stored by checksum of everything which was
+// used to produce them (source of the generator and its command, or source of the solution and the input)
+type TestCache struct {
+	dir string
+}
+
+// NewTestCache creates cache in the directory, polyglot/tests in the user cache directory is used by default
+func NewTestCache(dir string) *TestCache {
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			base = os.TempDir()
+		}
+		dir = filepath.Join(base, "polyglot", "tests")
+	}
+
+	return &TestCache{dir: dir}
+}
+
+// TestCacheKey returns checksum of the parts
+func TestCacheKey(parts ...[]byte) string {
+	h := sha1.New()
+	for _, part := range parts {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get writes cached file into the path, false is returned if the file is not cached
+func (c *TestCache) Get(key, path string) bool {
+	if c == nil {
+		return false
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(c.dir, key))
+	if err != nil {
+		return false
+	}
+
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		log.Printf("Unable to write %v: %v", path, err)
+		return false
+	}
+
+	return true
+}
+
+// Put saves the file, errors are only logged since the cache is optional
+func (c *TestCache) Put(key string, data []byte) {
+	if c == nil {
+		return
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		log.Printf("Unable to create test cache %v: %v", c.dir, err)
+		return
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(c.dir, key), data, 0644); err != nil {
+		log.Printf("Unable to save %v into test cache: %v", key, err)
+	}
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"strings"
+)
+
+type testlibChecker struct {
+	kind          executor.Verifier_Type
+	precision     int32
+	caseSensitive bool
+}
+
+// testlibCheckers maps standard testlib checkers to Eolymp verifiers, precision is the number of digits after the
+// decimal point and zero means tokens are compared exactly. uncmp (unordered integers) and pointscmp have no
+// equivalent, so they are imported from the source code.
+var testlibCheckers = map[string]testlibChecker{
+	"acmp":     {executor.Verifier_TOKENS, 6, true},  // single double, max absolute error 1.5E-6
+	"caseicmp": {executor.Verifier_TOKENS, 0, true},  // "Case #N:" followed by a single int64 in each case
+	"casencmp": {executor.Verifier_TOKENS, 0, true},  // "Case #N:" followed by int64 sequences
+	"casewcmp": {executor.Verifier_TOKENS, 0, true},  // "Case #N:" followed by token sequences
+	"dcmp":     {executor.Verifier_TOKENS, 6, true},  // single double, max absolute or relative error 1E-6
+	"fcmp":     {executor.Verifier_LINES, 0, false},  // lines, doesn't ignore whitespaces
+	"hcmp":     {executor.Verifier_TOKENS, 0, true},  // single huge integer
+	"icmp":     {executor.Verifier_TOKENS, 0, true},  // single int32
+	"lcmp":     {executor.Verifier_LINES, 0, false},  // lines, ignores whitespaces
+	"ncmp":     {executor.Verifier_TOKENS, 0, true},  // single or more int64, ignores whitespaces
+	"nyesno":   {executor.Verifier_TOKENS, 0, false}, // zero or more yes/no, case insensitive
+	"rcmp":     {executor.Verifier_TOKENS, 6, true},  // single or more double, max absolute error 1.5E-6
+	"rcmp4":    {executor.Verifier_TOKENS, 4, true},  // single or more double, max absolute or relative error 1E-4
+	"rcmp6":    {executor.Verifier_TOKENS, 6, true},  // single or more double, max absolute or relative error 1E-6
+	"rcmp9":    {executor.Verifier_TOKENS, 9, true},  // single or more double, max absolute or relative error 1E-9
+	"rncmp":    {executor.Verifier_TOKENS, 5, true},  // sequence of doubles, max absolute error 1.5E-5
+	"wcmp":     {executor.Verifier_TOKENS, 0, true},  // sequence of tokens
+	"yesno":    {executor.Verifier_TOKENS, 0, false}, // single yes or no, case insensitive
+}
+
+// TestlibVerifier returns verifier for standard testlib checker, name can be given as "std::rcmp4.cpp", "rcmp4.cpp"
+// or "rcmp4"
+func TestlibVerifier(name string) (*executor.Verifier, bool) {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "std::"), ".cpp")
+
+	checker, ok := testlibCheckers[name]
+	if !ok {
+		return nil, false
+	}
+
+	return &executor.Verifier{Type: checker.kind, Precision: checker.precision, CaseSensitive: checker.caseSensitive}, true
+}
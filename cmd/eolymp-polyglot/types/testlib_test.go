@@ -0,0 +1,49 @@
+package types_test
+
+import (
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"testing"
+)
+
+func TestTestlibVerifier(t *testing.T) {
+	tt := []struct {
+		name          string
+		ok            bool
+		kind          executor.Verifier_Type
+		precision     int32
+		caseSensitive bool
+	}{
+		{name: "std::rcmp4.cpp", ok: true, kind: executor.Verifier_TOKENS, precision: 4, caseSensitive: true},
+		{name: "rcmp6.cpp", ok: true, kind: executor.Verifier_TOKENS, precision: 6, caseSensitive: true},
+		{name: "rcmp9", ok: true, kind: executor.Verifier_TOKENS, precision: 9, caseSensitive: true},
+		{name: "std::rncmp.cpp", ok: true, kind: executor.Verifier_TOKENS, precision: 5, caseSensitive: true},
+		{name: "std::acmp.cpp", ok: true, kind: executor.Verifier_TOKENS, precision: 6, caseSensitive: true},
+		{name: "std::wcmp.cpp", ok: true, kind: executor.Verifier_TOKENS, caseSensitive: true},
+		{name: "std::ncmp.cpp", ok: true, kind: executor.Verifier_TOKENS, caseSensitive: true},
+		{name: "std::yesno.cpp", ok: true, kind: executor.Verifier_TOKENS},
+		{name: "nyesno", ok: true, kind: executor.Verifier_TOKENS},
+		{name: "std::fcmp.cpp", ok: true, kind: executor.Verifier_LINES},
+		{name: "lcmp.cpp", ok: true, kind: executor.Verifier_LINES},
+		{name: "std::uncmp.cpp"},
+		{name: "std::pointscmp.cpp"},
+		{name: "files/check.cpp"},
+		{name: ""},
+	}
+
+	for _, tc := range tt {
+		verifier, ok := types.TestlibVerifier(tc.name)
+		if ok != tc.ok {
+			t.Errorf("Checker %#v is found: %v, expected %v", tc.name, ok, tc.ok)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if verifier.GetType() != tc.kind || verifier.GetPrecision() != tc.precision || verifier.GetCaseSensitive() != tc.caseSensitive {
+			t.Errorf("Checker %#v is mapped to %v, expected %v with precision %v, case sensitive %v", tc.name, verifier, tc.kind, tc.precision, tc.caseSensitive)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package types
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/ecm"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TpsImporter imports tasks prepared with Task Preparation System: problem.json, subtasks.json, tests/mapping,
+// checker/, graders/ and statement/
+type TpsImporter struct {
+	Importer
+	spec     *TpsSpecification
+	subtasks []TpsSubtask
+	mapping  map[string][]string
+	path     string
+	context  context.Context
+	ts       *typewriter.TypewriterService
+	kpr      *keeper.KeeperService
+}
+
+// TpsSpecification is problem.json of the task
+type TpsSpecification struct {
+	Name        string  `json:"name"`
+	Code        string  `json:"code"`
+	Title       string  `json:"title"`
+	Type        string  `json:"type"`
+	TimeLimit   float64 `json:"time_limit"`
+	MemoryLimit int     `json:"memory_limit"`
+	HasGrader   bool    `json:"has_grader"`
+	HasChecker  bool    `json:"has_checker"`
+	HasManager  bool    `json:"has_manager"`
+}
+
+type TpsSubtask struct {
+	Name  string
+	Index uint32  `json:"index"`
+	Score float64 `json:"score"`
+}
+
+// tpsGraderRuntimes maps grader directories (or extensions of grader files) to Eolymp runtimes
+var tpsGraderRuntimes = map[string][]string{
+	"cpp":  {"cpp:17-gnu10"},
+	"java": {"java"},
+	"pas":  {"fpc"},
+	"py":   {"python", "pypy"},
+}
+
+func CreateTpsImporter(path string, context context.Context, ts *typewriter.TypewriterService, kpr *keeper.KeeperService) (*TpsImporter, error) {
+	importer := new(TpsImporter)
+	importer.path = path
+	importer.context = context
+	importer.ts = ts
+	importer.kpr = kpr
+
+	data, err := ioutil.ReadFile(filepath.Join(path, "problem.json"))
+	if err != nil {
+		log.Printf("Unable to read problem.json: %v", err)
+		return nil, err
+	}
+
+	importer.spec = &TpsSpecification{}
+	if err := json.Unmarshal(data, importer.spec); err != nil {
+		log.Printf("Unable to parse problem.json: %v", err)
+		return nil, err
+	}
+
+	data, err = ioutil.ReadFile(filepath.Join(path, "subtasks.json"))
+	if err != nil {
+		log.Printf("Unable to read subtasks.json: %v", err)
+		return nil, err
+	}
+
+	var subtasks struct {
+		Subtasks map[string]TpsSubtask `json:"subtasks"`
+	}
+
+	if err := json.Unmarshal(data, &subtasks); err != nil {
+		log.Printf("Unable to parse subtasks.json: %v", err)
+		return nil, err
+	}
+
+	for name, subtask := range subtasks.Subtasks {
+		subtask.Name = name
+		importer.subtasks = append(importer.subtasks, subtask)
+	}
+
+	sort.Slice(importer.subtasks, func(i, j int) bool { return importer.subtasks[i].Index < importer.subtasks[j].Index })
+
+	importer.mapping, err = readTpsMapping(filepath.Join(path, "tests", "mapping"))
+	if err != nil {
+		log.Printf("Unable to read tests/mapping: %v", err)
+		return nil, err
+	}
+
+	return importer, nil
+}
+
+// readTpsMapping reads "subtask test" lines of tests/mapping, tests keep the order of the file
+func readTpsMapping(path string) (map[string][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	mapping := map[string][]string{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		mapping[fields[0]] = append(mapping[fields[0]], fields[1])
+	}
+
+	return mapping, scanner.Err()
+}
+
+func (imp TpsImporter) GetVerifier() (*executor.Verifier, error) {
+	if imp.spec.HasChecker {
+		lang, source, ok, err := readProgram(filepath.Join(imp.path, "checker"), "checker")
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, errors.New("checker is not found in checker/")
+		}
+
+		// TPS checkers use testlib of CMS, which prints the score to stdout like CMS checkers do
+		if !strings.HasPrefix(lang, "cpp") {
+			return nil, fmt.Errorf("TPS checkers in %v are not supported, only C++ checkers can be adapted to testlib", lang)
+		}
+
+		return &executor.Verifier{Type: executor.Verifier_PROGRAM, Source: cmsCheckerAdapter(source), Lang: lang}, nil
+	}
+
+	return &executor.Verifier{Type: executor.Verifier_TOKENS, Precision: 0, CaseSensitive: true}, nil
+}
+
+func (imp TpsImporter) HasInteractor() bool {
+	return imp.spec.HasManager || strings.EqualFold(imp.spec.Type, "Communication")
+}
+
+// GetInteractor fails, managers of TPS communication tasks are CMS managers, which can not be mapped to testlib
+// interactor
+func (imp TpsImporter) GetInteractor() (*executor.Interactor, error) {
+	return nil, errors.New("communication tasks with a manager are not supported")
+}
+
+// GetStatements reads statement/*.md and statement/*.tex, statement.pdf is uploaded when there is no source
+func (imp TpsImporter) GetStatements(source string) ([]*atlas.Statement, error) {
+	title := imp.spec.Title
+	if title == "" {
+		title = imp.spec.Name
+	}
+
+	dir := filepath.Join(imp.path, "statement")
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var statements []*atlas.Statement
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if ext != ".md" && ext != ".tex" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := UpdateContentWithPictures(imp.context, imp.ts, string(data), dir+"/")
+		if err != nil {
+			return nil, err
+		}
+
+		content := &ecm.Content{Value: &ecm.Content_Latex{Latex: text}}
+		if ext == ".md" {
+			content = &ecm.Content{Value: &ecm.Content_Markdown{Markdown: text}}
+		}
+
+		statements = append(statements, &atlas.Statement{
+			Locale:  LocaleFromFileName(file.Name(), "en"),
+			Title:   title,
+			Content: content,
+			Source:  source,
+		})
+	}
+
+	if len(statements) > 0 {
+		return statements, nil
+	}
+
+	statement, err := UploadPdfStatement(imp.context, imp.ts, filepath.Join(dir, "statement.pdf"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	statement.Locale = "en"
+	statement.Title = title
+	statement.Source = source
+
+	return []*atlas.Statement{statement}, nil
+}
+
+func (imp TpsImporter) GetSolutions() ([]*atlas.Editorial, error) {
+	return nil, nil
+}
+
+func (imp TpsImporter) GetTestsets() ([]*Group, error) {
+	timeLimit := uint32(math.Round(imp.spec.TimeLimit * 1000))
+	if timeLimit == 0 {
+		log.Println("Time limit is not set in problem.json, using 1 second")
+		timeLimit = 1000
+	}
+
+	memoryLimit := uint64(imp.spec.MemoryLimit) * 1024 * 1024
+	if memoryLimit == 0 {
+		memoryLimit = 256 * 1024 * 1024
+	}
+
+	// every test is uploaded once even if it belongs to several subtasks
+	var names []string
+	seen := map[string]bool{}
+	for _, subtask := range imp.subtasks {
+		for _, name := range imp.mapping[subtask.Name] {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	var paths []TestPath
+	for _, name := range names {
+		paths = append(paths, TestPath{
+			input:  filepath.Join(imp.path, "tests", name+".in"),
+			output: filepath.Join(imp.path, "tests", name+".out"),
+		})
+	}
+
+	uploaded, err := UploadTestPaths(imp.context, imp.kpr, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	tests := map[string]*atlas.Test{}
+	for i, name := range names {
+		tests[name] = uploaded[i]
+	}
+
+	var groups []*Group
+	for si, subtask := range imp.subtasks {
+		list := imp.mapping[subtask.Name]
+		if len(list) == 0 {
+			log.Printf("Subtask %v has no tests, skipping", subtask.Name)
+			continue
+		}
+
+		xts := &atlas.Testset{
+			Index:          subtask.Index,
+			TimeLimit:      timeLimit,
+			MemoryLimit:    memoryLimit,
+			FileSizeLimit:  536870912,
+			ScoringMode:    atlas.ScoringMode_WORST,
+			FeedbackPolicy: atlas.FeedbackPolicy_COMPLETE,
+		}
+
+		// subtask depends on earlier subtasks whose tests it contains
+		for _, prev := range imp.subtasks[:si] {
+			if prev.Score > 0 && len(imp.mapping[prev.Name]) > 0 && containsAll(list, imp.mapping[prev.Name]) {
+				xts.Dependencies = append(xts.Dependencies, prev.Index)
+			}
+		}
+
+		examples := subtask.Score == 0 && subtask.Index == 0
+
+		group := &Group{Testset: xts, Name: subtask.Index}
+		for ti, name := range list {
+			group.Tests = append(group.Tests, &atlas.Test{
+				Index:          int32(ti + 1),
+				Example:        examples,
+				Score:          float32(subtask.Score),
+				InputObjectId:  tests[name].InputObjectId,
+				AnswerObjectId: tests[name].AnswerObjectId,
+			})
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// GetTemplates returns grader template for every language found in graders/. Graders are either grouped in
+// directories named by language (graders/cpp/, graders/java/) or stored together, in which case files are grouped
+// by extension and C++ headers go with C++ graders.
+func (imp TpsImporter) GetTemplates(pid *string) ([]*atlas.Template, error) {
+	if !imp.spec.HasGrader {
+		return nil, nil
+	}
+
+	dir := filepath.Join(imp.path, "graders")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	graders := map[string][]string{}
+	for _, file := range files {
+		if file.IsDir() {
+			if _, ok := tpsGraderRuntimes[file.Name()]; !ok {
+				continue
+			}
+
+			nested, err := ioutil.ReadDir(filepath.Join(dir, file.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, n := range nested {
+				if !n.IsDir() {
+					graders[file.Name()] = append(graders[file.Name()], filepath.Join(dir, file.Name(), n.Name()))
+				}
+			}
+			continue
+		}
+
+		// manager is the interactor, not a part of the template
+		if strings.HasPrefix(file.Name(), "manager") {
+			continue
+		}
+
+		lang := strings.TrimPrefix(filepath.Ext(file.Name()), ".")
+		switch lang {
+		case "h", "hpp", "cc":
+			lang = "cpp"
+		}
+
+		if _, ok := tpsGraderRuntimes[lang]; ok {
+			graders[lang] = append(graders[lang], filepath.Join(dir, file.Name()))
+		}
+	}
+
+	var templates []*atlas.Template
+	for _, lang := range SortedKeys(graders) {
+		for _, runtime := range tpsGraderRuntimes[lang] {
+			template, err := MakeGraderTemplate(*pid, runtime, graders[lang], imp.kpr)
+			if err != nil {
+				return nil, err
+			}
+
+			if template != nil {
+				templates = append(templates, template)
+			}
+		}
+	}
+
+	return templates, nil
+}
+
+// GetAttachments uploads files from public/ which are given to contestants
+func (imp TpsImporter) GetAttachments(pid *string) ([]*atlas.Attachment, error) {
+	return UploadAttachments(imp.context, imp.ts, filepath.Join(imp.path, "public"), *pid)
+}
@@ -0,0 +1,114 @@
+package types_test
+
+import (
+	"context"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/executor"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTpsTestsets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"problem.json":  `{"name": "sum", "title": "Sum", "type": "Batch", "time_limit": 1.5, "memory_limit": 64}`,
+		"subtasks.json": `{"subtasks": {"samples": {"index": 0, "score": 0}, "small": {"index": 1, "score": 30}, "full": {"index": 2, "score": 70}, "empty": {"index": 3, "score": 0}}}`,
+		"tests/mapping": "samples 0-01\nsmall 0-01\nsmall 1-01\nfull 0-01\nfull 1-01\nfull 2-01\n",
+		"tests/0-01.in": "1 2\n", "tests/0-01.out": "3\n",
+		"tests/1-01.in": "2 2\n", "tests/1-01.out": "4\n",
+		"tests/2-01.in": "9 9\n", "tests/2-01.out": "18\n",
+	})
+
+	imp, err := types.CreateTpsImporter(dir, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := imp.GetTestsets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// subtask without tests is skipped
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 testsets, got %v", len(groups))
+	}
+
+	tt := []struct {
+		index        uint32
+		tests        int
+		score        float32
+		example      bool
+		dependencies []uint32
+	}{
+		{index: 0, tests: 1, score: 0, example: true},
+		{index: 1, tests: 2, score: 30},
+		{index: 2, tests: 3, score: 70, dependencies: []uint32{1}},
+	}
+
+	for i, tc := range tt {
+		testset := groups[i].Testset
+		if testset.GetIndex() != tc.index || len(groups[i].Tests) != tc.tests {
+			t.Errorf("Testset %v is %v with %v tests, expected %v with %v tests", i, testset.GetIndex(), len(groups[i].Tests), tc.index, tc.tests)
+			continue
+		}
+
+		if testset.GetTimeLimit() != 1500 || testset.GetMemoryLimit() != 64<<20 || testset.GetScoringMode() != atlas.ScoringMode_WORST {
+			t.Errorf("Testset %v has wrong limits or scoring: %v", tc.index, testset)
+		}
+
+		if !reflect.DeepEqual(testset.GetDependencies(), tc.dependencies) {
+			t.Errorf("Testset %v depends on %v, expected %v", tc.index, testset.GetDependencies(), tc.dependencies)
+		}
+
+		for _, test := range groups[i].Tests {
+			if test.GetScore() != tc.score || test.GetExample() != tc.example {
+				t.Errorf("Test of testset %v has score %v and example %v, expected %v and %v", tc.index, test.GetScore(), test.GetExample(), tc.score, tc.example)
+			}
+		}
+	}
+
+	// the same test in several subtasks refers to the same object
+	if groups[0].Tests[0].GetInputObjectId() != groups[2].Tests[0].GetInputObjectId() {
+		t.Error("Shared test has different objects in different subtasks")
+	}
+}
+
+func TestTpsCheckerAndManager(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"problem.json":        `{"name": "sum", "title": "Sum", "type": "Communication", "has_checker": true, "has_manager": true}`,
+		"subtasks.json":       `{"subtasks": {"samples": {"index": 0, "score": 0}}}`,
+		"tests/mapping":       "samples 0-01\n",
+		"tests/0-01.in":       "tps 1 2\n",
+		"tests/0-01.out":      "tps 3\n",
+		"checker/checker.cpp": "#include \"testlib.h\"\nint main(int argc, char** argv) { registerChecker(\"sum\", argc, argv); }\n",
+		"checker/testlib.h":   "// testlib of CMS\n",
+		"graders/manager.cpp": "int main() {}\n",
+	})
+
+	imp, err := types.CreateTpsImporter(dir, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := imp.GetVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checker prints the score like CMS checkers, so it is wrapped into the adapter
+	if verifier.GetType() != executor.Verifier_PROGRAM || !strings.Contains(verifier.GetSource(), "// testlib of CMS") || !strings.Contains(verifier.GetSource(), "cms_finish") {
+		t.Errorf("Checker is not wrapped into the adapter: %v", verifier.GetSource())
+	}
+
+	if !imp.HasInteractor() {
+		t.Fatal("Communication task must have an interactor")
+	}
+
+	if _, err := imp.GetInteractor(); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Manager must be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,411 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Files are not uploaded while the plan is built. Importers get placeholders made of SHA-1 of the content instead of
+// keys and links, and ResolveUploads uploads the files when the plan is applied, so a dry run does not upload
+// anything. Files found in the blob cache get their cached keys and links right away. Uploaded files are dropped from
+// the pending uploads, only their keys and links are kept for placeholders used again.
+
+var placeholderPattern = regexp.MustCompile(`pending-(object|asset):[0-9a-f]{40}`)
+
+// pendingUpload is a file waiting to be uploaded to keeper (kpr is set) or to typewriter (tw is set)
+type pendingUpload struct {
+	path string // file is read again on upload, so tests are not kept in memory
+	data []byte
+	name string
+	sha  string
+	size int64
+	kpr  *keeper.KeeperService
+	tw   *typewriter.TypewriterService
+
+	once  sync.Once
+	value string
+	err   error
+}
+
+var pending = struct {
+	sync.Mutex
+	uploads  map[string]*pendingUpload
+	resolved map[string]string // keys and links of uploaded placeholders
+}{uploads: map[string]*pendingUpload{}, resolved: map[string]string{}}
+
+// deferObject returns placeholder for keeper object, path is used if data is nil
+func deferObject(kpr *keeper.KeeperService, path string, data []byte) (string, error) {
+	content := data
+	if content == nil {
+		var err error
+		if content, err = ioutil.ReadFile(path); err != nil {
+			return "", err
+		}
+	}
+
+	sha := hashContent(content)
+	if blobs != nil {
+		if key, ok := blobs.Lookup(context.Background(), sha, int64(len(content))); ok {
+			log.Println("Cached", key)
+			return key, nil
+		}
+	}
+
+	return addPending("pending-object:"+sha, &pendingUpload{path: path, data: data, sha: sha, size: int64(len(content)), kpr: kpr}), nil
+}
+
+// UploadAsset returns link of typewriter asset with the same content uploaded before, or placeholder which is replaced
+// with the link when the plan is applied
+func UploadAsset(tw *typewriter.TypewriterService, name string, data []byte) string {
+	sha := hashContent(data)
+	if blobs != nil {
+		if link, ok := blobs.LookupAsset(sha, int64(len(data))); ok {
+			log.Println("Cached", link)
+			return link
+		}
+	}
+
+	return addPending("pending-asset:"+sha, &pendingUpload{name: name, data: data, sha: sha, size: int64(len(data)), tw: tw})
+}
+
+// addPending registers the upload, the same content registered again replaces the upload which is not done yet: the
+// file of the earlier import may be removed by now
+func addPending(placeholder string, upload *pendingUpload) string {
+	pending.Lock()
+	defer pending.Unlock()
+
+	if _, ok := pending.resolved[placeholder]; !ok {
+		pending.uploads[placeholder] = upload
+	}
+
+	return placeholder
+}
+
+func hashContent(data []byte) string {
+	h := sha1.New()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// upload sends the file once, concurrent calls wait for the first one
+func (u *pendingUpload) upload(ctx context.Context) (string, error) {
+	u.once.Do(func() {
+		u.value, u.err = u.send(ctx)
+	})
+
+	return u.value, u.err
+}
+
+func (u *pendingUpload) send(ctx context.Context) (string, error) {
+	data := u.data
+	if data == nil {
+		var err error
+		if data, err = ioutil.ReadFile(u.path); err != nil {
+			return "", err
+		}
+	}
+
+	if u.tw == nil {
+		return UploadObject(u.kpr, bytes.NewReader(data))
+	}
+
+	out, err := u.tw.UploadAsset(ctx, &typewriter.UploadAssetInput{Filename: u.name, Data: data})
+	if err != nil {
+		log.Printf("Unable to upload %v: %v", u.name, err)
+		return "", err
+	}
+
+	log.Println(u.name, "has been uploaded")
+	if blobs != nil {
+		blobs.SaveAsset(u.sha, out.Link, int64(len(data)))
+	}
+
+	return out.Link, nil
+}
+
+// ResolveUploads uploads files referenced by placeholders in string fields of the message and replaces the
+// placeholders with object keys and asset links
+func ResolveUploads(ctx context.Context, message proto.Message) error {
+	return resolveMessage(ctx, message.ProtoReflect())
+}
+
+func resolveMessage(ctx context.Context, m protoreflect.Message) error {
+	var err error
+	changed := map[protoreflect.FieldDescriptor]string{}
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				switch fd.Kind() {
+				case protoreflect.StringKind:
+					var value string
+					if value, err = resolveString(ctx, list.Get(i).String()); err == nil {
+						list.Set(i, protoreflect.ValueOfString(value))
+					}
+				case protoreflect.MessageKind, protoreflect.GroupKind:
+					err = resolveMessage(ctx, list.Get(i).Message())
+				}
+			}
+		case fd.Kind() == protoreflect.StringKind:
+			var value string
+			if value, err = resolveString(ctx, v.String()); err == nil && value != v.String() {
+				changed[fd] = value
+			}
+		case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+			err = resolveMessage(ctx, v.Message())
+		}
+
+		return err == nil
+	})
+
+	// the message is not modified while its fields are iterated
+	for fd, value := range changed {
+		m.Set(fd, protoreflect.ValueOfString(value))
+	}
+
+	return err
+}
+
+func resolveString(ctx context.Context, value string) (string, error) {
+	for _, placeholder := range placeholderPattern.FindAllString(value, -1) {
+		pending.Lock()
+		upload, ok := pending.uploads[placeholder]
+		resolved, done := pending.resolved[placeholder]
+		pending.Unlock()
+
+		if !done {
+			if !ok {
+				return "", fmt.Errorf("file %v is not known", placeholder)
+			}
+
+			var err error
+			if resolved, err = upload.upload(ctx); err != nil {
+				return "", err
+			}
+
+			// content of the uploaded file is not needed anymore
+			pending.Lock()
+			pending.resolved[placeholder] = resolved
+			if pending.uploads[placeholder] == upload {
+				delete(pending.uploads, placeholder)
+			}
+			pending.Unlock()
+		}
+
+		value = strings.ReplaceAll(value, placeholder, resolved)
+	}
+
+	return value, nil
+}
+
+// EqualUploads is like proto.Equal, but strings of the planned message may have placeholders, which are compared with
+// MatchUploads to keys and links of the described message
+func EqualUploads(ctx context.Context, planned, described proto.Message) bool {
+	return equalMessage(ctx, planned.ProtoReflect(), described.ProtoReflect())
+}
+
+func equalMessage(ctx context.Context, a, b protoreflect.Message) bool {
+	if a.IsValid() != b.IsValid() || a.Descriptor().FullName() != b.Descriptor().FullName() {
+		return false
+	}
+
+	fields := a.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if a.Has(fd) != b.Has(fd) {
+			return false
+		}
+
+		if !a.Has(fd) {
+			continue
+		}
+
+		switch va, vb := a.Get(fd), b.Get(fd); {
+		case fd.IsMap():
+			ma, mb := va.Map(), vb.Map()
+			if ma.Len() != mb.Len() {
+				return false
+			}
+
+			equal := true
+			ma.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				equal = mb.Has(k) && equalValue(ctx, fd.MapValue(), v, mb.Get(k))
+				return equal
+			})
+
+			if !equal {
+				return false
+			}
+		case fd.IsList():
+			la, lb := va.List(), vb.List()
+			if la.Len() != lb.Len() {
+				return false
+			}
+
+			for j := 0; j < la.Len(); j++ {
+				if !equalValue(ctx, fd, la.Get(j), lb.Get(j)) {
+					return false
+				}
+			}
+		default:
+			if !equalValue(ctx, fd, va, vb) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func equalValue(ctx context.Context, fd protoreflect.FieldDescriptor, a, b protoreflect.Value) bool {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return MatchUploads(ctx, a.String(), b.String())
+	case protoreflect.BytesKind:
+		return bytes.Equal(a.Bytes(), b.Bytes())
+	case protoreflect.EnumKind:
+		return a.Enum() == b.Enum()
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return equalMessage(ctx, a.Message(), b.Message())
+	default:
+		return a.Interface() == b.Interface()
+	}
+}
+
+// MatchUploads reports whether the planned value, which may have placeholders, is the same as the described value with
+// keys and links of files uploaded before. The files are downloaded and compared by SHA-1, so a problem imported
+// without the blob cache is not updated again. Matching files are saved in the blob cache and their placeholders are
+// resolved to the existing keys and links, so they are not uploaded.
+func MatchUploads(ctx context.Context, planned, described string) bool {
+	locations := placeholderPattern.FindAllStringIndex(planned, -1)
+	if len(locations) == 0 {
+		return planned == described
+	}
+
+	// placeholders may be surrounded by text, like pictures in the statement
+	pattern, last := "(?s)^", 0
+	for _, loc := range locations {
+		pattern += regexp.QuoteMeta(planned[last:loc[0]]) + "(.+?)"
+		last = loc[1]
+	}
+	pattern += regexp.QuoteMeta(planned[last:]) + "$"
+
+	match := regexp.MustCompile(pattern).FindStringSubmatch(described)
+	if match == nil {
+		return false
+	}
+
+	for i, loc := range locations {
+		if !matchUpload(ctx, planned[loc[0]:loc[1]], match[i+1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchUpload(ctx context.Context, placeholder, value string) bool {
+	pending.Lock()
+	upload, ok := pending.uploads[placeholder]
+	resolved, done := pending.resolved[placeholder]
+	pending.Unlock()
+
+	if done || !ok {
+		return done && resolved == value
+	}
+
+	data, err := upload.download(ctx, value)
+	if err != nil {
+		log.Printf("Unable to compare %v with %v: %v", placeholder, value, err)
+		return false
+	}
+
+	if data == nil || hashContent(data) != upload.sha {
+		return false
+	}
+
+	if blobs != nil {
+		if upload.tw == nil {
+			blobs.Save(upload.sha, value, upload.size)
+		} else {
+			blobs.SaveAsset(upload.sha, value, upload.size)
+		}
+	}
+
+	pending.Lock()
+	pending.resolved[placeholder] = value
+	if pending.uploads[placeholder] == upload {
+		delete(pending.uploads, placeholder)
+	}
+	pending.Unlock()
+
+	return true
+}
+
+// download returns content of the keeper object or typewriter asset uploaded before, nil is returned without reading
+// the content if its size is different
+func (u *pendingUpload) download(ctx context.Context, value string) ([]byte, error) {
+	if u.tw == nil {
+		if u.kpr == nil {
+			return nil, errors.New("keeper is not configured")
+		}
+
+		desc, err := u.kpr.DescribeObject(ctx, &keeper.DescribeObjectInput{Key: value})
+		if err != nil {
+			return nil, err
+		}
+
+		if int64(desc.GetSize()) != u.size {
+			return nil, nil
+		}
+
+		out, err := u.kpr.DownloadObject(ctx, &keeper.DownloadObjectInput{Key: value})
+		if err != nil {
+			return nil, err
+		}
+
+		return out.GetData(), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, value, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v", resp.Status)
+	}
+
+	if resp.ContentLength >= 0 && resp.ContentLength != u.size {
+		return nil, nil
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, u.size+1))
+}
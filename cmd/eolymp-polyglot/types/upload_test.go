@@ -0,0 +1,148 @@
+package types_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/ecm"
+	"github.com/eolymp/go-sdk/eolymp/keeper"
+	"github.com/eolymp/go-sdk/eolymp/typewriter"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"github.com/eolymp/polyglot/cmd/store"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResolveUploads(t *testing.T) {
+	var uploads int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&uploads, 1)
+		fmt.Fprintf(rw, `{"key":"object-%v"}`, n)
+	}))
+	defer srv.Close()
+
+	kpr := keeper.NewKeeperHttpClient(srv.URL, srv.Client())
+
+	input, err := types.MakeObjectByData([]byte("resolve uploads\n"), kpr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := types.MakeObjectByData([]byte("resolve uploads\n"), kpr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(input, "pending-object:") || input != answer {
+		t.Fatalf("Expected the same placeholder for the same content, got %#v and %#v", input, answer)
+	}
+
+	if n := atomic.LoadInt32(&uploads); n != 0 {
+		t.Fatalf("Expected nothing to be uploaded before the plan is applied, got %v uploads", n)
+	}
+
+	test := &atlas.Test{InputObjectId: input, AnswerObjectId: answer}
+	if err := types.ResolveUploads(context.Background(), test); err != nil {
+		t.Fatal(err)
+	}
+
+	if test.InputObjectId != "object-1" || test.AnswerObjectId != "object-1" {
+		t.Errorf("Placeholders are not replaced: %v", test)
+	}
+
+	if n := atomic.LoadInt32(&uploads); n != 1 {
+		t.Errorf("Expected a single upload, got %v", n)
+	}
+}
+
+func TestResolveUploadsRemovedFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"key":"object-1"}`)
+	}))
+	defer srv.Close()
+
+	kpr := keeper.NewKeeperHttpClient(srv.URL, srv.Client())
+
+	// the first import reads the test from its directory, which is removed before the plan is applied
+	path := filepath.Join(t.TempDir(), "01")
+	if err := os.WriteFile(path, []byte("removed test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := types.MakeObjectGetFile(path, kpr); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next import has the same content
+	key, err := types.MakeObjectByData([]byte("removed test\n"), kpr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := &atlas.Test{InputObjectId: key}
+	if err := types.ResolveUploads(context.Background(), test); err != nil {
+		t.Fatal(err)
+	}
+
+	if test.InputObjectId != "object-1" {
+		t.Errorf("Placeholder is not replaced: %v", test)
+	}
+}
+
+func TestUploadAssetCache(t *testing.T) {
+	var uploads int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&uploads, 1)
+		fmt.Fprintf(rw, `{"link":"https://static.example.com/asset-%v.png"}`, n)
+	}))
+	defer srv.Close()
+
+	db := store.New(filepath.Join(t.TempDir(), "state.json"))
+	types.SetBlobCache(types.NewBlobCache(db, nil, "https://api.example.com", "space", false))
+	defer types.SetBlobCache(nil)
+
+	tw := typewriter.NewTypewriterHttpClient(srv.URL, srv.Client())
+
+	placeholder := types.UploadAsset(tw, "picture.png", []byte("cached picture"))
+	if !strings.HasPrefix(placeholder, "pending-asset:") {
+		t.Fatalf("Expected placeholder for the new picture, got %#v", placeholder)
+	}
+
+	// the same picture is used twice in the plan
+	statements := []*atlas.Statement{
+		{Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: "\\includegraphics{" + placeholder + "}"}}},
+		{DownloadLink: placeholder},
+	}
+
+	for _, statement := range statements {
+		if err := types.ResolveUploads(context.Background(), statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := statements[1].GetDownloadLink(); got != "https://static.example.com/asset-1.png" {
+		t.Errorf("Placeholder is resolved to %#v", got)
+	}
+
+	if got := statements[0].GetContent().GetLatex(); got != "\\includegraphics{https://static.example.com/asset-1.png}" {
+		t.Errorf("Placeholder in content is resolved to %#v", got)
+	}
+
+	// the next import gets the link from the cache
+	if link := types.UploadAsset(tw, "picture.png", []byte("cached picture")); link != "https://static.example.com/asset-1.png" {
+		t.Errorf("Expected cached link, got %#v", link)
+	}
+
+	if n := atomic.LoadInt32(&uploads); n != 1 {
+		t.Errorf("Expected a single upload, got %v", n)
+	}
+}
@@ -1,14 +1,12 @@
 package types
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/eolymp/go-sdk/eolymp/atlas"
+	"github.com/eolymp/go-sdk/eolymp/ecm"
 	"github.com/eolymp/go-sdk/eolymp/keeper"
 	"github.com/eolymp/go-sdk/eolymp/typewriter"
 	"io"
@@ -18,6 +16,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -35,19 +34,7 @@ func UpdateContentWithPictures(ctx context.Context, tw *typewriter.TypewriterSer
 				log.Println("Failed to read file " + file)
 				return "", err
 			}
-			var output *typewriter.UploadAssetOutput
-			for i := 0; i < RepeatNumber; i++ {
-				output, err = tw.UploadAsset(ctx, &typewriter.UploadAssetInput{Filename: file, Data: data})
-				if err == nil {
-					break
-				}
-				log.Println("Error while uploading asset")
-			}
-			if err != nil {
-				log.Println("Error while uploading asset")
-				return "", err
-			}
-			content = strings.ReplaceAll(content, file, output.Link)
+			content = strings.ReplaceAll(content, file, UploadAsset(tw, file, data))
 		}
 	}
 	return content, nil
@@ -88,18 +75,17 @@ func MakeObject(path string, kpr *keeper.KeeperService) (key string, err error)
 	return output, err
 }
 
+// MakeObjectGetFile returns key of the file in keeper, the file is uploaded by ResolveUploads
 func MakeObjectGetFile(path string, kpr *keeper.KeeperService) (key string, err error) {
-
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return "", err
-	}
-
-	return MakeObjectByData(data, kpr)
+	return deferObject(kpr, path, nil)
 }
 
+// MakeObjectByData returns key of the data in keeper, the data is uploaded by ResolveUploads
 func MakeObjectByData(data []byte, kpr *keeper.KeeperService) (key string, err error) {
-	return UploadObject(kpr, bytes.NewReader(data))
+	if data == nil {
+		data = []byte{}
+	}
+	return deferObject(kpr, "", data)
 }
 
 func UploadObject(kpr *keeper.KeeperService, reader io.Reader) (string, error) {
@@ -114,13 +100,15 @@ func UploadObject(kpr *keeper.KeeperService, reader io.Reader) (string, error) {
 	sha := hex.EncodeToString(h.Sum(nil))
 	log.Println(sha)
 
-	if val, ok := GetCacheValue(sha); ok {
-		log.Println("Cached", val)
-		return val, nil
-	}
-
 	size := len(data)
 
+	if blobs != nil {
+		if val, ok := blobs.Lookup(context.Background(), sha, int64(size)); ok {
+			log.Println("Cached", val)
+			return val, nil
+		}
+	}
+
 	maxSize := 5242880
 
 	// single call API for a small object
@@ -131,7 +119,9 @@ func UploadObject(kpr *keeper.KeeperService, reader io.Reader) (string, error) {
 		}
 		log.Println(out.Key)
 
-		SetCacheValue(sha, out.Key)
+		if blobs != nil {
+			blobs.Save(sha, out.Key, int64(size))
+		}
 		return out.Key, nil
 	}
 
@@ -185,7 +175,9 @@ func UploadObject(kpr *keeper.KeeperService, reader io.Reader) (string, error) {
 	}
 
 	key, err := upload.GetObjectId(), nil
-	SetCacheValue(sha, key)
+	if blobs != nil {
+		blobs.Save(sha, key, int64(size))
+	}
 
 	return key, err
 }
@@ -316,61 +308,170 @@ func GetTestsFromTexStatement(data string, kpr *keeper.KeeperService) (tests []*
 	return tests, nil
 }
 
-func GetExamplesFromLocation(path string, kpr *keeper.KeeperService) (tests []*atlas.Test, err error) {
-	tests, err = GetTestsFromLocation(path, kpr)
-	if err != nil {
-		return tests, err
+// OlympHeader returns arguments of \begin{problem}{name}{input}{output}{time}{memory} header used by olymp.sty,
+// nil is returned if there is no header
+func OlympHeader(data string) []string {
+	args, _ := olympHeader(data)
+	return args
+}
+
+// olympHeader returns arguments of \begin{problem} header and the offset right after the last of them
+func olympHeader(data string) ([]string, int) {
+	start := strings.Index(data, "\\begin{problem}")
+	if start < 0 {
+		return nil, -1
 	}
-	for i := 0; i < len(tests); i++ {
-		tests[i].Example = true
+
+	var args []string
+	rest := data[start+len("\\begin{problem}"):]
+	for len(args) < 5 {
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if !strings.HasPrefix(rest, "{") {
+			break
+		}
+
+		// arguments may contain nested braces, like {\textit{stdin}}
+		depth, end := 0, -1
+		for i, c := range rest {
+			if c == '{' {
+				depth++
+			} else if c == '}' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+
+		if end < 0 {
+			break
+		}
+
+		args = append(args, strings.TrimSpace(rest[1:end]))
+		rest = rest[end+1:]
 	}
-	return tests, nil
-}
 
-func GetCacheValue(s string) (string, bool) {
-	val, ok := GetCache()[s]
-	return val, ok
+	return args, len(data) - len(rest)
 }
 
-func SetCacheValue(key string, value string) {
-	cache := GetCache()
-	log.Println("Set", key, value)
-	cache[key] = value
-	SaveCache(cache)
+var limitNumber = regexp.MustCompile(`[0-9]+([.,][0-9]+)?`)
+
+// ParseTimeLimit parses time limit written in the statement, like "1 second", "0.5 секунды" or "500 ms", into
+// milliseconds
+func ParseTimeLimit(value string) (uint32, bool) {
+	number := limitNumber.FindString(value)
+	if number == "" {
+		return 0, false
+	}
+
+	v, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	unit := strings.ToLower(value[strings.Index(value, number)+len(number):])
+	if strings.Contains(unit, "ms") || strings.Contains(unit, "мс") || strings.Contains(unit, "milli") || strings.Contains(unit, "милли") || strings.Contains(unit, "мілі") {
+		return uint32(v), true
+	}
+
+	return uint32(v * 1000), true
 }
 
-func SaveCache(data map[string]string) {
-	json, err := json.Marshal(data)
+// ParseMemoryLimit parses memory limit written in the statement, like "256 megabytes", "64 МБ" or "1 GB", into bytes,
+// megabytes are assumed if there is no unit
+func ParseMemoryLimit(value string) (uint64, bool) {
+	number := limitNumber.FindString(value)
+	if number == "" {
+		return 0, false
+	}
+
+	v, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
 	if err != nil {
-		panic(err)
+		return 0, false
+	}
+
+	unit := strings.ToLower(strings.TrimSpace(value[strings.Index(value, number)+len(number):]))
+	switch {
+	case strings.HasPrefix(unit, "k") || strings.HasPrefix(unit, "к"):
+		return uint64(v * 1024), true
+	case strings.HasPrefix(unit, "g") || strings.HasPrefix(unit, "г"):
+		return uint64(v * 1024 * 1024 * 1024), true
+	default:
+		return uint64(v * 1024 * 1024), true
 	}
-	ioutil.WriteFile("cache.json", json, 0644)
 }
 
-func GetCache() map[string]string {
-	jsonFile, err := os.Open("cache.json")
+// GetTutorialsFromLocation reads every *tutorial*.tex file in the directory and
+// turns it into an editorial, the locale is taken from the file name.
+func GetTutorialsFromLocation(ctx context.Context, tw *typewriter.TypewriterService, path string) ([]*atlas.Editorial, error) {
+	files, err := ioutil.ReadDir(path)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			err = os.WriteFile("cache.json", []byte("{}"), 0644)
-			if err != nil {
-				log.Println("Can't create file")
-				log.Fatal(err)
-			}
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
-		jsonFile, err = os.Open("cache.json")
+		return nil, err
+	}
+
+	var editorials []*atlas.Editorial
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".tex" || !strings.Contains(file.Name(), "tutorial") {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(path, file.Name()))
 		if err != nil {
-			log.Println("Can't open file")
-			log.Fatal(err)
+			return nil, err
+		}
+
+		content, err := UpdateContentWithPictures(ctx, tw, string(data), path+"/")
+		if err != nil {
+			return nil, err
 		}
+
+		log.Println("Found tutorial", file.Name())
+		editorials = append(editorials, &atlas.Editorial{
+			Locale:  LocaleFromFileName(file.Name(), "uk"),
+			Content: &ecm.Content{Value: &ecm.Content_Latex{Latex: content}},
+		})
 	}
-	defer jsonFile.Close()
-	byteValue, err := ioutil.ReadAll(jsonFile)
+	return editorials, nil
+}
+
+// LocaleFromFileName looks for a language marker (ua, uk, ru, en, ...) among
+// the parts of a file name like "tutorial_en.tex" or "statement-ru.tex".
+func LocaleFromFileName(name, fallback string) string {
+	parts := strings.FieldsFunc(strings.TrimSuffix(name, filepath.Ext(name)), func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+	for _, part := range parts {
+		switch strings.ToLower(part) {
+		case "ua", "uk", "ukr", "ukrainian":
+			return "uk"
+		case "ru", "rus", "russian":
+			return "ru"
+		case "en", "eng", "english":
+			return "en"
+		case "pl", "polish":
+			return "pl"
+		case "kk", "kazakh":
+			return "kk"
+		case "hu", "hungarian":
+			return "hu"
+		}
+	}
+	return fallback
+}
+
+func GetExamplesFromLocation(path string, kpr *keeper.KeeperService) (tests []*atlas.Test, err error) {
+	tests, err = GetTestsFromLocation(path, kpr)
 	if err != nil {
-		panic(err)
+		return tests, err
+	}
+	for i := 0; i < len(tests); i++ {
+		tests[i].Example = true
 	}
-	var result map[string]string
-	json.Unmarshal(byteValue, &result)
-	return result
+	return tests, nil
 }
 
 func AddPointsToTests(g *Group) {
@@ -382,3 +483,161 @@ func AddPointsToTests(g *Group) {
 		g.Tests[i].Score = float32(score)
 	}
 }
+
+// UploadTestPaths uploads input and answer of every test, tests are indexed from 1 in the order of paths. Tests
+// without answer path get an empty answer.
+func UploadTestPaths(ctx context.Context, kpr *keeper.KeeperService, paths []TestPath) ([]*atlas.Test, error) {
+	tests := make([]*atlas.Test, len(paths))
+	err := Parallel(ctx, len(paths), func(ctx context.Context, i int) error {
+		input, err := MakeObject(paths[i].input, kpr)
+		if err != nil {
+			log.Printf("Unable to upload test input data to E-Olymp: %v", err)
+			return err
+		}
+
+		var answer string
+		if paths[i].output == "" {
+			answer, err = MakeObjectByData([]byte{}, kpr)
+		} else {
+			answer, err = MakeObject(paths[i].output, kpr)
+		}
+		if err != nil {
+			log.Printf("Unable to upload test answer data to E-Olymp: %v", err)
+			return err
+		}
+
+		log.Printf("Uploaded test %v", paths[i].input)
+		tests[i] = &atlas.Test{Index: int32(i + 1), InputObjectId: input, AnswerObjectId: answer}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tests, nil
+}
+
+var extensionLanguages = map[string]string{
+	".c":    "cpp:17-gnu10",
+	".cc":   "cpp:17-gnu10",
+	".cpp":  "cpp:17-gnu10",
+	".cxx":  "cpp:17-gnu10",
+	".cs":   "csharp",
+	".go":   "go",
+	".java": "java",
+	".kt":   "kotlin",
+	".pas":  "fpc",
+	".dpr":  "fpc",
+	".py":   "python",
+	".rb":   "ruby",
+	".rs":   "rust",
+}
+
+// LanguageByExtension returns Eolymp runtime for the source file
+func LanguageByExtension(name string) (string, bool) {
+	lang, ok := extensionLanguages[strings.ToLower(filepath.Ext(name))]
+	return lang, ok
+}
+
+var localInclude = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*include[ \t]*(?:"([^"]+)"|<(testlib\.h)>)[ \t]*(?://.*)?\r?$`)
+
+// InlineIncludes replaces #include "file" directives (and #include <testlib.h>) with content of the file from the
+// directory, so a program split into several files can be sent as a single source. Each file is inlined once.
+func InlineIncludes(dir, source string) string {
+	return inlineIncludes(dir, source, map[string]bool{})
+}
+
+func inlineIncludes(dir, source string, seen map[string]bool) string {
+	return localInclude.ReplaceAllStringFunc(source, func(line string) string {
+		match := localInclude.FindStringSubmatch(line)
+		name := match[1] + match[2]
+		path := filepath.Join(dir, name)
+		if seen[path] {
+			return ""
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return line
+		}
+
+		seen[path] = true
+		return inlineIncludes(filepath.Dir(path), string(data), seen)
+	})
+}
+
+// UploadPdfStatement uploads PDF file and returns statement linked to it, the caller fills locale and title
+func UploadPdfStatement(ctx context.Context, tw *typewriter.TypewriterService, path string) (*atlas.Statement, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &atlas.Statement{
+		Content:      &ecm.Content{Value: &ecm.Content_Latex{Latex: ""}},
+		DownloadLink: UploadAsset(tw, filepath.Base(path), data),
+	}, nil
+}
+
+// UploadAttachments uploads every file of the directory as problem attachment, missing directory means no attachments
+func UploadAttachments(ctx context.Context, tw *typewriter.TypewriterService, dir, pid string) ([]*atlas.Attachment, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var attachments []*atlas.Attachment
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, &atlas.Attachment{ProblemId: pid, Name: file.Name(), Link: UploadAsset(tw, file.Name(), data)})
+	}
+
+	return attachments, nil
+}
+
+// MakeGraderTemplate uploads grader files and returns template which compiles them together with submissions in the
+// runtime, nil is returned if there are no files
+func MakeGraderTemplate(pid, runtime string, paths []string, kpr *keeper.KeeperService) (*atlas.Template, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	template := &atlas.Template{}
+	template.ProblemId = pid
+	template.Runtime = runtime
+	for _, path := range paths {
+		obj, err := MakeObjectGetFile(path, kpr)
+		if err != nil {
+			fmt.Println("Failed to read grader")
+			return nil, err
+		}
+
+		fileName := filepath.Base(path)
+		template.Files = append(template.Files, &atlas.File{
+			Path:      fileName,
+			SourceErn: "ern:blob:" + obj, // TODO FIX IT
+		})
+	}
+
+	return template, nil
+}
+
+// SortedKeys returns keys of the map in ascending order, so maps are traversed in the same order every time
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
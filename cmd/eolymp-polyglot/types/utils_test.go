@@ -0,0 +1,163 @@
+package types_test
+
+import (
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"reflect"
+	"testing"
+)
+
+func TestInlineIncludes(t *testing.T) {
+	tt := []struct {
+		name   string
+		files  map[string]string
+		source string
+		want   string
+	}{
+		{
+			name:   "local",
+			files:  map[string]string{"util.h": "int add(int a, int b);"},
+			source: "#include <cstdio>\n#include \"util.h\"\nint main() {}\n",
+			want:   "#include <cstdio>\nint add(int a, int b);\nint main() {}\n",
+		},
+		{
+			name:   "testlib",
+			files:  map[string]string{"testlib.h": "// testlib"},
+			source: "#include <testlib.h>\n# include \"testlib.h\" // again\n",
+			want:   "// testlib\n\n",
+		},
+		{
+			name:   "nested",
+			files:  map[string]string{"lib/a.h": "#include \"b.h\"\nint a;", "lib/b.h": "int b;"},
+			source: "#include \"lib/a.h\"\n#include \"lib/b.h\"\n",
+			want:   "int b;\nint a;\n\n",
+		},
+		{
+			name:   "cycle",
+			files:  map[string]string{"a.h": "#include \"b.h\"\nint a;", "b.h": "#include \"a.h\"\nint b;"},
+			source: "#include \"a.h\"\n",
+			want:   "\nint b;\nint a;\n",
+		},
+		{
+			name:   "crlf and blank lines",
+			files:  map[string]string{"util.h": "int x;"},
+			source: "\r\n#include \"util.h\"\r\n\r\nint main() {}\r\n",
+			want:   "\r\nint x;\n\r\nint main() {}\r\n",
+		},
+		{
+			name:   "missing",
+			source: "#include \"missing.h\"\n#include <testlib.h>\n",
+			want:   "#include \"missing.h\"\n#include <testlib.h>\n",
+		},
+		{
+			name:   "not a directive",
+			files:  map[string]string{"util.h": "int x;"},
+			source: "const char* s = \"#include \\\"util.h\\\"\";\n",
+			want:   "const char* s = \"#include \\\"util.h\\\"\";\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+
+			if got := types.InlineIncludes(dir, tc.source); got != tc.want {
+				t.Errorf("Inlined source is %#v, expected %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOlympHeader(t *testing.T) {
+	tt := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "full",
+			data: "\\begin{problem}{Sum}{standard input}{standard output}{1 second}{256 megabytes}\n\nFind a + b.",
+			want: []string{"Sum", "standard input", "standard output", "1 second", "256 megabytes"},
+		},
+		{
+			name: "nested braces",
+			data: "\\begin{problem}{Sum of \\textit{a} and \\textit{b}} {\\texttt{sum.in}}\n{stdout}{0,5 с}{64 МБ}",
+			want: []string{"Sum of \\textit{a} and \\textit{b}", "\\texttt{sum.in}", "stdout", "0,5 с", "64 МБ"},
+		},
+		{
+			name: "short",
+			data: "\\begin{problem}{Sum}{}{}\nFind a + b.",
+			want: []string{"Sum", "", ""},
+		},
+		{
+			name: "unclosed",
+			data: "\\begin{problem}{Sum}{stdin",
+			want: []string{"Sum"},
+		},
+		{
+			name: "missing",
+			data: "\\section{Sum}",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := types.OlympHeader(tc.data); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Header is %#v, expected %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeLimit(t *testing.T) {
+	tt := []struct {
+		value string
+		want  uint32
+		ok    bool
+	}{
+		{value: "1 second", want: 1000, ok: true},
+		{value: "2 seconds", want: 2000, ok: true},
+		{value: "0.5 секунды", want: 500, ok: true},
+		{value: "1,5 с", want: 1500, ok: true},
+		{value: "500 ms", want: 500, ok: true},
+		{value: "250 мс", want: 250, ok: true},
+		{value: "100 milliseconds", want: 100, ok: true},
+		{value: "300 мілісекунд", want: 300, ok: true},
+		{value: "3", want: 3000, ok: true},
+		{value: "unlimited", ok: false},
+		{value: "", ok: false},
+	}
+
+	for _, tc := range tt {
+		got, ok := types.ParseTimeLimit(tc.value)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Time limit %#v is parsed as %v (%v), expected %v (%v)", tc.value, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestParseMemoryLimit(t *testing.T) {
+	tt := []struct {
+		value string
+		want  uint64
+		ok    bool
+	}{
+		{value: "256 megabytes", want: 256 << 20, ok: true},
+		{value: "64 МБ", want: 64 << 20, ok: true},
+		{value: "64 мегабайти", want: 64 << 20, ok: true},
+		{value: "1 GB", want: 1 << 30, ok: true},
+		{value: "0,5 гигабайта", want: 512 << 20, ok: true},
+		{value: "65536 kilobytes", want: 64 << 20, ok: true},
+		{value: "1024 КБ", want: 1 << 20, ok: true},
+		{value: "128", want: 128 << 20, ok: true},
+		{value: "unlimited", ok: false},
+		{value: "", ok: false},
+	}
+
+	for _, tc := range tt {
+		got, ok := types.ParseMemoryLimit(tc.value)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Memory limit %#v is parsed as %v (%v), expected %v (%v)", tc.value, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha1"
+	"encoding/hex"
 	"github.com/eolymp/go-sdk/eolymp/atlas"
-	"io/ioutil"
+	"github.com/eolymp/polyglot/cmd/store"
+	"io"
 	"log"
 	"os"
 	"time"
@@ -26,30 +28,94 @@ func CreateProblem(ctx context.Context) (string, error) {
 	return "", nil
 }
 
-func SaveData(data map[string]interface{}) {
-	json, err := json.Marshal(data)
+// GetTestsetSlots returns positions of Polygon testsets recorded by the previous import of the problem.
+func GetTestsetSlots(pid string) map[string]uint32 {
+	slots := map[string]uint32{}
+	if pid == "" {
+		return slots
+	}
+
+	err := db.View(func(state *store.State) error {
+		for name, slot := range state.Testsets[pid] {
+			slots[name] = slot
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to read testset positions: %v", err)
+	}
+
+	return slots
+}
+
+func SaveTestsetSlots(pid string, slots map[string]uint32) {
+	err := db.Update(func(state *store.State) error {
+		state.Testsets[pid] = slots
+		return nil
+	})
+	if err != nil {
+		log.Printf("Unable to save testset positions: %v", err)
+	}
+}
+
+// GetProblemRecord returns record of the problem imported from the link
+func GetProblemRecord(link string) (store.Problem, bool) {
+	var record store.Problem
+	var found bool
+
+	err := db.View(func(state *store.State) error {
+		if p, ok := state.Problems[link]; ok {
+			record, found = *p, true
+		}
+		return nil
+	})
 	if err != nil {
-		panic(err)
+		log.Printf("Unable to read problem record: %v", err)
 	}
-	ioutil.WriteFile("data.json", json, 0644)
+
+	return record, found
 }
 
-func GetData() map[string]interface{} {
-	// TODO create file if does not exist
-	jsonFile, err := os.Open("data.json")
+// RecordImport adds problem import to the history
+func RecordImport(source, pid, format string, started time.Time, err error) {
+	record := &store.Import{
+		Source:    source,
+		ProblemId: pid,
+		Format:    format,
+		StartedAt: started,
+		Duration:  time.Since(started).Seconds(),
+	}
+
 	if err != nil {
-		panic(err)
+		record.Error = err.Error()
+	}
+
+	if err := db.Update(func(state *store.State) error {
+		state.AddImport(record)
+		return nil
+	}); err != nil {
+		log.Printf("Unable to save import history: %v", err)
 	}
-	defer jsonFile.Close()
-	byteValue, err := ioutil.ReadAll(jsonFile)
+}
+
+// FileHash returns hex encoded SHA-1 checksum of the file
+func FileHash(path string) (string, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	var result map[string]interface{}
-	json.Unmarshal(byteValue, &result)
-	return result
+	defer file.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 func SpaceIdToLink(spaceId string) string {
 	return conf.Eolymp.ApiUrl + "/spaces/" + spaceId
 }
+
+func NewEditorialService(spaceId, pid string) *atlas.EditorialServiceService {
+	return atlas.NewEditorialServiceHttpClient(SpaceIdToLink(spaceId)+"/problems/"+pid, client)
+}
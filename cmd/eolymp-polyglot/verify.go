@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/eolymp/polyglot/cmd/eolymp-polyglot/types"
+	"github.com/eolymp/polyglot/cmd/runner"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VerifyProblem judges reference solutions of Polygon package locally and checks that their verdicts match their
+// tags, nothing is uploaded to Eolymp
+func VerifyProblem(path, format string) error {
+	ctx := types.ContextWithJobs(context.Background(), jobs)
+
+	path, cleanup, err := OpenWorkspace(path)
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	if format == types.FormatAuto {
+		if format, err = types.DetectFormat(path); err != nil {
+			return err
+		}
+	}
+
+	if format != types.FormatPolygon {
+		return fmt.Errorf("problems in %#v format can not be verified", format)
+	}
+
+	imp, err := types.CreatePolygonImporter(path, ctx, tw, kpr)
+	if err != nil {
+		return err
+	}
+
+	if err := imp.SetTestsetPolicy(testsetPolicy, nil); err != nil {
+		return err
+	}
+
+	if err := imp.GenerateTests(compiler, testCache); err != nil {
+		return err
+	}
+
+	if imp.HasInteractor() {
+		return errors.New("interactive problems can not be verified locally")
+	}
+
+	verifier, err := imp.GetVerifier()
+	if err != nil {
+		return err
+	}
+
+	dir, err := ioutil.TempDir("", "polyglot-verify-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	judge, err := types.NewLocalJudge(ctx, compiler, verifier, dir, filepath.Join(path, "files"))
+	if err != nil {
+		return err
+	}
+
+	tests := imp.LocalTests()
+	solutions := imp.LocalSolutions()
+	if len(solutions) == 0 {
+		return errors.New("package has no solutions")
+	}
+
+	mismatched, skipped := 0, 0
+	for _, solution := range solutions {
+		outcome, err := verifySolution(ctx, judge, solution, tests)
+		if err != nil {
+			return err
+		}
+
+		switch outcome {
+		case outcomeMismatched:
+			mismatched++
+		case outcomeSkipped:
+			skipped++
+		}
+	}
+
+	if mismatched > 0 {
+		return fmt.Errorf("%v of %v solutions do not match their tags, %v are skipped", mismatched, len(solutions), skipped)
+	}
+
+	if skipped > 0 {
+		log.Printf("%v of %v solutions match their tags, %v are skipped", len(solutions)-skipped, len(solutions), skipped)
+		return nil
+	}
+
+	log.Printf("All %v solutions match their tags", len(solutions))
+
+	return nil
+}
+
+type verifyOutcome int
+
+const (
+	outcomeMatched verifyOutcome = iota
+	outcomeMismatched
+	outcomeSkipped // the tag is unknown or the solution can not be run locally
+)
+
+// verifySolution runs the solution on all tests and reports if its verdicts match the tag, a solution which does not
+// compile gets a single compilation error verdict
+func verifySolution(ctx context.Context, judge *types.LocalJudge, solution types.LocalSolution, tests []types.LocalTest) (verifyOutcome, error) {
+	name := filepath.Base(solution.Path)
+
+	if _, err := types.PolygonTagMatches(solution.Tag, nil); err != nil {
+		log.Printf("Solution %v is skipped: %v", name, err)
+		return outcomeSkipped, nil
+	}
+
+	log.Printf("Judging %v (%v)", name, solution.Tag)
+
+	verdicts := map[types.Verdict]int{}
+	duration := time.Duration(0)
+	memory := uint64(0)
+
+	program, err := judge.Prepare(ctx, solution.Path)
+	if errors.Is(err, runner.ErrCompilation) {
+		log.Printf("Solution %v is not compiled: %v", name, err)
+		verdicts[types.VerdictCompilationError]++
+	} else if err != nil {
+		log.Printf("Solution %v is skipped: %v", name, err)
+		return outcomeSkipped, nil
+	} else {
+		results := make([]*types.LocalResult, len(tests))
+		err = types.Parallel(ctx, len(tests), func(ctx context.Context, i int) error {
+			result, err := judge.Judge(ctx, program, tests[i])
+			if err != nil {
+				return fmt.Errorf("unable to judge %v on test %v of testset %#v: %w", name, tests[i].Index, tests[i].Testset, err)
+			}
+
+			results[i] = result
+			return nil
+		})
+
+		if err != nil {
+			return outcomeMismatched, err
+		}
+
+		for i, result := range results {
+			verdicts[result.Verdict]++
+
+			if result.Duration > duration {
+				duration = result.Duration
+			}
+
+			if result.Memory > memory {
+				memory = result.Memory
+			}
+
+			if result.Verdict != types.VerdictAccepted {
+				log.Printf("  test %v of testset %#v: %v", tests[i].Index, tests[i].Testset, strings.TrimSpace(fmt.Sprint(result.Verdict, " ", result.Message)))
+			}
+		}
+	}
+
+	var summary []string
+	for verdict, count := range verdicts {
+		summary = append(summary, fmt.Sprintf("%v %v", count, verdict))
+	}
+
+	sort.Strings(summary)
+
+	matches, err := types.PolygonTagMatches(solution.Tag, verdicts)
+	if err != nil {
+		return outcomeMismatched, err
+	}
+
+	if verdicts[types.VerdictCheckerFailed] > 0 {
+		matches = false
+	}
+
+	status, outcome := "matches", outcomeMatched
+	if !matches {
+		status, outcome = "DOES NOT match", outcomeMismatched
+	}
+
+	log.Printf("%v: %v, max time %v, max memory %v MB, %v tag %#v", name, strings.Join(summary, ", "), duration.Round(time.Millisecond), memory>>20, status, solution.Tag)
+
+	return outcome, nil
+}
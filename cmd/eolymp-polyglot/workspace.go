@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mholt/archiver"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OpenWorkspace prepares the source given to ip. Archives and http(s) links are unpacked into a temporary
+// workspace and the package directory inside it is returned, other sources are returned as is. The returned
+// function removes the workspace.
+func OpenWorkspace(source string) (string, func(), error) {
+	noop := func() {}
+
+	remote := IsRemoteSource(source)
+	if !remote {
+		info, err := os.Stat(source)
+		if err != nil || info.IsDir() {
+			return source, noop, nil
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "polyglot-")
+	if err != nil {
+		return "", noop, err
+	}
+
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Unable to remove workspace %v: %v", dir, err)
+		}
+	}
+
+	archive := source
+	if remote {
+		if archive, err = DownloadArchive(source, dir); err != nil {
+			cleanup()
+			return "", noop, err
+		}
+	}
+
+	location := filepath.Join(dir, "package")
+	if err := UnpackArchive(archive, location); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+
+	location, err = TopLevelFolder(location)
+	if err != nil {
+		cleanup()
+		return "", noop, err
+	}
+
+	log.Printf("Unpacked %v into %v", source, location)
+
+	return location, cleanup, nil
+}
+
+// IsRemoteSource checks if the source is http(s) link
+func IsRemoteSource(source string) bool {
+	u, err := url.Parse(source)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// DownloadArchive downloads archive into the directory, the name of the file is taken from Content-Disposition
+// header or from the link itself
+func DownloadArchive(link, dir string) (string, error) {
+	log.Printf("Downloading %v", link)
+
+	c := &http.Client{Timeout: 300 * time.Second}
+
+	response, err := c.Get(link)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to download %v: %v", link, response.Status)
+	}
+
+	name := ""
+	if _, params, err := mime.ParseMediaType(response.Header.Get("Content-Disposition")); err == nil {
+		name = filepath.Base(params["filename"])
+	}
+
+	if _, err := archiveFormat(name); name == "" || err != nil {
+		u, _ := url.Parse(link)
+		name = path.Base(u.Path)
+	}
+
+	if _, err := archiveFormat(name); err != nil {
+		return "", err
+	}
+
+	location := filepath.Join(dir, name)
+
+	file, err := os.Create(location)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if _, err = io.Copy(file, response.Body); err != nil {
+		return "", err
+	}
+
+	return location, nil
+}
+
+// UnpackArchive unpacks zip, tar, tar.gz, tar.bz2, tar.xz or rar archive into the location
+func UnpackArchive(archive, location string) error {
+	format, err := archiveFormat(archive)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(location, 0777); err != nil {
+		return err
+	}
+
+	if err := format.Unarchive(archive, location); err != nil {
+		log.Printf("Unable to unpack %v: %v", archive, err)
+		return err
+	}
+
+	return nil
+}
+
+func archiveFormat(name string) (archiver.Unarchiver, error) {
+	if strings.HasSuffix(strings.ToLower(name), ".7z") {
+		return nil, errors.New("7z archives are not supported, unpack the package or repack it as zip")
+	}
+
+	format, err := archiver.ByExtension(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to tell archive format of %#v: %w", name, err)
+	}
+
+	unarchiver, ok := format.(archiver.Unarchiver)
+	if !ok {
+		return nil, fmt.Errorf("%#v is not an archive", name)
+	}
+
+	return unarchiver, nil
+}
+
+// TopLevelFolder skips directories which only wrap the package, archives often have a single folder with the
+// package inside. Hidden files and __MACOSX added by archivers are ignored.
+func TopLevelFolder(location string) (string, error) {
+	for {
+		files, err := ioutil.ReadDir(location)
+		if err != nil {
+			return "", err
+		}
+
+		var entries []os.FileInfo
+		for _, file := range files {
+			if strings.HasPrefix(file.Name(), ".") || file.Name() == "__MACOSX" {
+				continue
+			}
+			entries = append(entries, file)
+		}
+
+		if len(entries) != 1 || !entries[0].IsDir() {
+			return location, nil
+		}
+
+		location = filepath.Join(location, entries[0].Name())
+	}
+}
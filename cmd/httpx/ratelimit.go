@@ -0,0 +1,42 @@
+package httpx
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithRateLimit spreads outgoing HTTP calls so no more than rps calls are made per second, zero disables the limit
+func WithRateLimit(rps float64) func(Client) Client {
+	return func(c Client) Client {
+		if rps <= 0 {
+			return c
+		}
+
+		interval := time.Duration(float64(time.Second) / rps)
+
+		var mu sync.Mutex
+		var next time.Time
+
+		return ClientFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			now := time.Now()
+			if next.Before(now) {
+				next = now
+			}
+			wait := next.Sub(now)
+			next = next.Add(interval)
+			mu.Unlock()
+
+			if wait > 0 {
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(wait):
+				}
+			}
+
+			return c.Do(req)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package httpx_test
+
+import (
+	"github.com/eolymp/polyglot/cmd/httpx"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cli := httpx.NewClient(
+		&http.Client{Timeout: time.Second},
+		httpx.WithRateLimit(50),
+	)
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Error("Request can not be created:", err)
+				return
+			}
+
+			resp, err := cli.Do(req)
+			if err != nil {
+				t.Error("Request to test server has failed:", err)
+				return
+			}
+
+			defer resp.Body.Close() //nolint:errcheck
+		}()
+	}
+
+	wg.Wait()
+
+	// 5 requests at 50 rps are spread over at least 4 intervals of 20ms
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Requests are not rate limited, 5 requests took %v", elapsed)
+	}
+}
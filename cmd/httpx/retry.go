@@ -8,6 +8,8 @@ import (
 	"time"
 )
 
+// WithRetry repeats requests which fail with server errors, client errors like 404 are returned right away, except
+// for 408 and 429 which are worth another attempt
 func WithRetry(retries int) func(Client) Client {
 	return func(c Client) Client {
 		return ClientFunc(func(req *http.Request) (resp *http.Response, err error) {
@@ -29,17 +31,26 @@ func WithRetry(retries int) func(Client) Client {
 					return nil, err
 				}
 
-				if int(resp.StatusCode/100) != 2 {
-					body, _ = ioutil.ReadAll(resp.Body)
-					log.Printf("Server returned an error, status code %d: %s", resp.StatusCode, body)
-					time.Sleep(time.Second * time.Duration((attempt+1)*(attempt+1)))
-					continue
+				if int(resp.StatusCode/100) == 2 || !retryable(resp.StatusCode) {
+					return resp, nil
 				}
 
-				return resp, nil
+				msg, _ := ioutil.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				resp.Body = ioutil.NopCloser(bytes.NewReader(msg))
+
+				log.Printf("Server returned an error, status code %d: %s", resp.StatusCode, msg)
+
+				if attempt+1 < retries {
+					time.Sleep(time.Second * time.Duration((attempt+1)*(attempt+1)))
+				}
 			}
 
 			return resp, err
 		})
 	}
 }
+
+func retryable(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
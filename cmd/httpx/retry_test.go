@@ -0,0 +1,67 @@
+package httpx_test
+
+import (
+	"github.com/eolymp/polyglot/cmd/httpx"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRetry(t *testing.T) {
+	tt := []struct {
+		name     string
+		statuses []int
+		status   int
+		attempts int
+	}{
+		{name: "not found is final", statuses: []int{404}, status: 404, attempts: 1},
+		{name: "server error is retried", statuses: []int{500, 200}, status: 200, attempts: 2},
+		{name: "too many requests is retried", statuses: []int{429, 200}, status: 200, attempts: 2},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+				if string(body) != "request" {
+					t.Errorf("Attempt %v has body %#v, expected \"request\"", attempts+1, string(body))
+				}
+
+				rw.WriteHeader(tc.statuses[attempts])
+				_, _ = rw.Write([]byte("response"))
+				attempts++
+			}))
+			defer srv.Close()
+
+			cli := httpx.NewClient(&http.Client{Timeout: time.Second}, httpx.WithRetry(3))
+
+			req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("request"))
+			if err != nil {
+				t.Fatal("Request can not be created:", err)
+			}
+
+			resp, err := cli.Do(req)
+			if err != nil {
+				t.Fatal("Request to test server has failed:", err)
+			}
+
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.status {
+				t.Errorf("Status code is %v, expected %v", resp.StatusCode, tc.status)
+			}
+
+			if body, _ := io.ReadAll(resp.Body); string(body) != "response" {
+				t.Errorf("Response body is %#v, expected \"response\"", string(body))
+			}
+
+			if attempts != tc.attempts {
+				t.Errorf("Request is sent %v times, expected %v", attempts, tc.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package polygon
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/eolymp/polyglot/cmd/httpx"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultEndpoint = "https://polygon.codeforces.com/api"
+
+var (
+	ErrEmptyCredentials = errors.New("polygon: api key or secret is not configured")
+	ErrParsingResponse  = errors.New("polygon: unable to parse response")
+	ErrPackageFailed    = errors.New("polygon: package build has failed")
+	ErrPackageNotFound  = errors.New("polygon: package not found")
+	ErrProblemNotFound  = errors.New("polygon: problem not found")
+)
+
+// Error is returned when Polygon API responds with status FAILED
+type Error struct {
+	Method  string
+	Comment string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("polygon: %v has failed: %v", e.Method, e.Comment)
+}
+
+// Client for Polygon API, see https://polygon.codeforces.com/api/help
+type Client struct {
+	url          string
+	key          string
+	secret       string
+	client       httpx.Client
+	now          func() time.Time
+	nonce        func() string
+	pollInterval time.Duration
+}
+
+// NewClient for Polygon API, endpoint defaults to DefaultEndpoint when empty
+func NewClient(endpoint, key, secret string, opts ...Option) *Client {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+
+	cli := &Client{
+		url:          strings.TrimSuffix(endpoint, "/"),
+		key:          key,
+		secret:       secret,
+		client:       &http.Client{Timeout: 10 * time.Minute},
+		now:          time.Now,
+		nonce:        randomNonce,
+		pollInterval: 10 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(cli)
+	}
+
+	return cli
+}
+
+// ProblemInfo returns general information about the problem
+func (c *Client) ProblemInfo(ctx context.Context, problemId int) (*ProblemInfo, error) {
+	out := &ProblemInfo{}
+	if err := c.call(ctx, "problem.info", url.Values{"problemId": {strconv.Itoa(problemId)}}, out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Problem returns the problem with its current revision
+func (c *Client) Problem(ctx context.Context, problemId int) (*Problem, error) {
+	var out []*Problem
+	if err := c.call(ctx, "problems.list", url.Values{"id": {strconv.Itoa(problemId)}}, &out); err != nil {
+		return nil, err
+	}
+
+	for _, p := range out {
+		if p.Id == problemId {
+			return p, nil
+		}
+	}
+
+	return nil, ErrProblemNotFound
+}
+
+// ProblemPackages lists packages built for the problem
+func (c *Client) ProblemPackages(ctx context.Context, problemId int) ([]*Package, error) {
+	var out []*Package
+	if err := c.call(ctx, "problem.packages", url.Values{"problemId": {strconv.Itoa(problemId)}}, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// ProblemPackage downloads package archive of the given type (standard, linux or windows) into w
+func (c *Client) ProblemPackage(ctx context.Context, problemId, packageId int, kind string, w io.Writer) error {
+	params := url.Values{
+		"problemId": {strconv.Itoa(problemId)},
+		"packageId": {strconv.Itoa(packageId)},
+	}
+
+	if kind != "" {
+		params.Set("type", kind)
+	}
+
+	resp, err := c.do(ctx, "problem.package", params)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK || strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return c.decode("problem.package", resp.Body, nil)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// BuildPackage starts building a new package, full packages include generated tests
+func (c *Client) BuildPackage(ctx context.Context, problemId int, full, verify bool) error {
+	params := url.Values{
+		"problemId": {strconv.Itoa(problemId)},
+		"full":      {strconv.FormatBool(full)},
+		"verify":    {strconv.FormatBool(verify)},
+	}
+
+	return c.call(ctx, "problem.buildPackage", params, nil)
+}
+
+// BuildPackageAndWait starts building a new package and waits until Polygon finishes it
+func (c *Client) BuildPackageAndWait(ctx context.Context, problemId int, full, verify bool) (*Package, error) {
+	packages, err := c.ProblemPackages(ctx, problemId)
+	if err != nil {
+		return nil, err
+	}
+
+	last := 0
+	for _, p := range packages {
+		if p.Id > last {
+			last = p.Id
+		}
+	}
+
+	if err := c.BuildPackage(ctx, problemId, full, verify); err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.pollInterval):
+		}
+
+		packages, err := c.ProblemPackages(ctx, problemId)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range packages {
+			if p.Id <= last {
+				continue
+			}
+
+			switch p.State {
+			case PackageReady:
+				return p, nil
+			case PackageFailed:
+				return nil, fmt.Errorf("%w: %v", ErrPackageFailed, p.Comment)
+			}
+		}
+	}
+}
+
+// LatestPackage returns the most recent ready package, only full packages are considered if full is set
+func (c *Client) LatestPackage(ctx context.Context, problemId int, full bool) (*Package, error) {
+	packages, err := c.ProblemPackages(ctx, problemId)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Package
+	for _, p := range packages {
+		if p.State != PackageReady || (full && !p.Full()) {
+			continue
+		}
+
+		if latest == nil || p.Revision > latest.Revision || (p.Revision == latest.Revision && p.Id > latest.Id) {
+			latest = p
+		}
+	}
+
+	if latest == nil {
+		return nil, ErrPackageNotFound
+	}
+
+	return latest, nil
+}
+
+// ContestProblems returns problems of the contest indexed by their letter
+func (c *Client) ContestProblems(ctx context.Context, contestId int) (map[string]*Problem, error) {
+	out := map[string]*Problem{}
+	if err := c.call(ctx, "contest.problems", url.Values{"contestId": {strconv.Itoa(contestId)}}, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *Client) call(ctx context.Context, method string, params url.Values, out interface{}) error {
+	resp, err := c.do(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	return c.decode(method, resp.Body, out)
+}
+
+func (c *Client) decode(method string, body io.Reader, out interface{}) error {
+	var envelope struct {
+		Status  string          `json:"status"`
+		Comment string          `json:"comment"`
+		Result  json.RawMessage `json:"result"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&envelope); err != nil {
+		return ErrParsingResponse
+	}
+
+	if envelope.Status != "OK" {
+		return &Error{Method: method, Comment: envelope.Comment}
+	}
+
+	if out == nil || len(envelope.Result) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return ErrParsingResponse
+	}
+
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method string, params url.Values) (*http.Response, error) {
+	if c.key == "" || c.secret == "" {
+		return nil, ErrEmptyCredentials
+	}
+
+	query := c.sign(method, params)
+
+	req, err := http.NewRequest(http.MethodPost, c.url+"/"+method, strings.NewReader(query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.client.Do(req)
+}
+
+// sign adds apiKey, time and apiSig parameters as described in Polygon API documentation
+func (c *Client) sign(method string, params url.Values) url.Values {
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = append([]string(nil), v...)
+	}
+
+	query.Set("apiKey", c.key)
+	query.Set("time", strconv.FormatInt(c.now().Unix(), 10))
+
+	query.Set("apiSig", Signature(c.nonce(), method, query, c.secret))
+
+	return query
+}
+
+// Signature calculates apiSig value for the given nonce (6 characters), method and parameters
+func Signature(nonce, method string, params url.Values, secret string) string {
+	var pairs []string
+	for k, values := range params {
+		if k == "apiSig" {
+			continue
+		}
+
+		for _, v := range values {
+			pairs = append(pairs, k+"="+v)
+		}
+	}
+
+	sort.Strings(pairs)
+
+	hash := sha512.Sum512([]byte(nonce + "/" + method + "?" + strings.Join(pairs, "&") + "#" + secret))
+
+	return nonce + hex.EncodeToString(hash[:])
+}
+
+func randomNonce() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	nonce := make([]byte, 6)
+	for i := range nonce {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			panic(err)
+		}
+		nonce[i] = alphabet[n.Int64()]
+	}
+
+	return string(nonce)
+}
@@ -0,0 +1,232 @@
+package polygon_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/eolymp/polyglot/cmd/polygon"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePolygon emulates Polygon API, it verifies signatures and serves packages from memory
+type fakePolygon struct {
+	t        *testing.T
+	mu       sync.Mutex
+	packages []*polygon.Package
+	archive  []byte
+	builds   int
+}
+
+func (f *fakePolygon) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		f.t.Fatal("Unable to parse form:", err)
+	}
+
+	method := req.URL.Path[1:]
+	sig := req.PostForm.Get("apiSig")
+
+	if len(sig) < 6 || polygon.Signature(sig[:6], method, req.PostForm, "secret") != sig {
+		rw.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(rw, `{"status":"FAILED","comment":"apiSig: Incorrect signature"}`)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	switch method {
+	case "problem.info":
+		fmt.Fprint(rw, `{"status":"OK","result":{"inputFile":"stdin","outputFile":"stdout","interactive":false,"timeLimit":1000,"memoryLimit":256}}`)
+	case "problems.list":
+		if req.PostForm.Get("id") != "100" {
+			fmt.Fprint(rw, `{"status":"OK","result":[]}`)
+			return
+		}
+		fmt.Fprint(rw, `{"status":"OK","result":[{"id":100,"name":"sum","revision":12,"latestPackage":2}]}`)
+	case "problem.packages":
+		fmt.Fprint(rw, `{"status":"OK","result":[`)
+		for i, p := range f.packages {
+			if i > 0 {
+				fmt.Fprint(rw, ",")
+			}
+			fmt.Fprintf(rw, `{"id":%d,"revision":%d,"state":%q,"type":%q}`, p.Id, p.Revision, p.State, p.Type)
+		}
+		fmt.Fprint(rw, `]}`)
+
+		// builds are finished on the second poll
+		for _, p := range f.packages {
+			if p.State == polygon.PackageRunning {
+				p.State = polygon.PackageReady
+			}
+		}
+	case "problem.buildPackage":
+		f.builds++
+		f.packages = append(f.packages, &polygon.Package{Id: len(f.packages) + 1, Revision: 10, State: polygon.PackageRunning, Type: "windows"})
+		fmt.Fprint(rw, `{"status":"OK"}`)
+	case "problem.package":
+		if req.PostForm.Get("packageId") != "2" {
+			rw.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(rw, `{"status":"FAILED","comment":"packageId: Package not found"}`)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/zip")
+		_, _ = rw.Write(f.archive)
+	case "contest.problems":
+		fmt.Fprint(rw, `{"status":"OK","result":{"A":{"id":100,"name":"sum","revision":3},"B":{"id":200,"name":"product","revision":5}}}`)
+	default:
+		rw.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(rw, `{"status":"FAILED","comment":"unknown method %v"}`, method)
+	}
+}
+
+func newFakeClient(t *testing.T, secret string) (*polygon.Client, *fakePolygon) {
+	fake := &fakePolygon{t: t, archive: []byte("PK-archive")}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	cli := polygon.NewClient(srv.URL, "key", secret, polygon.WithPollInterval(time.Millisecond))
+
+	return cli, fake
+}
+
+func TestSignature(t *testing.T) {
+	params := url.Values{"problemId": {"1"}, "apiKey": {"xxx"}, "time": {"1234567890"}}
+
+	got := polygon.Signature("123456", "problem.info", params, "yyy")
+
+	// sha512 of "123456/problem.info?apiKey=xxx&problemId=1&time=1234567890#yyy"
+	if want := "123456c9bfd1280473d1896626adecac7d4569b573d6e023daf9039957dc18a4d6460459ffb031f412861eb31ec305dabc4fa44da31f5b36fc0c360ec978ebc69a3d50"; got != want {
+		t.Errorf("Signature is not correct: want %v, got %v", want, got)
+	}
+
+	if got != polygon.Signature("123456", "problem.info", url.Values{"time": {"1234567890"}, "problemId": {"1"}, "apiKey": {"xxx"}}, "yyy") {
+		t.Error("Signature depends on parameter order")
+	}
+
+	if got == polygon.Signature("123456", "problem.info", params, "zzz") {
+		t.Error("Signature does not depend on secret")
+	}
+}
+
+func TestClient_ProblemInfo(t *testing.T) {
+	cli, _ := newFakeClient(t, "secret")
+
+	info, err := cli.ProblemInfo(context.Background(), 100)
+	if err != nil {
+		t.Fatal("Unable to get problem info:", err)
+	}
+
+	if info.TimeLimit != 1000 || info.MemoryLimit != 256 || info.InputFile != "stdin" {
+		t.Errorf("Problem info is not correct: %+v", info)
+	}
+}
+
+func TestClient_InvalidSecret(t *testing.T) {
+	cli, _ := newFakeClient(t, "wrong")
+
+	_, err := cli.ProblemInfo(context.Background(), 100)
+
+	var perr *polygon.Error
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected polygon error, got %v", err)
+	}
+
+	if perr.Comment != "apiSig: Incorrect signature" {
+		t.Errorf("Error comment is not correct: %v", perr.Comment)
+	}
+}
+
+func TestClient_EmptyCredentials(t *testing.T) {
+	cli := polygon.NewClient("http://localhost", "", "")
+
+	if _, err := cli.ProblemInfo(context.Background(), 1); !errors.Is(err, polygon.ErrEmptyCredentials) {
+		t.Errorf("Expected ErrEmptyCredentials, got %v", err)
+	}
+}
+
+func TestClient_BuildPackageAndWait(t *testing.T) {
+	cli, fake := newFakeClient(t, "secret")
+	fake.packages = []*polygon.Package{{Id: 1, Revision: 9, State: polygon.PackageReady, Type: "standard"}}
+
+	pkg, err := cli.BuildPackageAndWait(context.Background(), 100, true, false)
+	if err != nil {
+		t.Fatal("Unable to build package:", err)
+	}
+
+	if pkg.Id != 2 || pkg.Revision != 10 || pkg.State != polygon.PackageReady {
+		t.Errorf("Package is not correct: %+v", pkg)
+	}
+
+	if fake.builds != 1 {
+		t.Errorf("Expected exactly one build, got %v", fake.builds)
+	}
+
+	latest, err := cli.LatestPackage(context.Background(), 100, true)
+	if err != nil {
+		t.Fatal("Unable to find latest package:", err)
+	}
+
+	if latest.Id != 2 {
+		t.Errorf("Latest package is not correct: %+v", latest)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := cli.ProblemPackage(context.Background(), 100, latest.Id, "windows", buf); err != nil {
+		t.Fatal("Unable to download package:", err)
+	}
+
+	if got, want := buf.String(), "PK-archive"; got != want {
+		t.Errorf("Package content is not correct: want %v, got %v", want, got)
+	}
+
+	if err := cli.ProblemPackage(context.Background(), 100, 1, "windows", buf); err == nil {
+		t.Error("Expected an error for missing package")
+	}
+}
+
+func TestClient_LatestPackageNotFound(t *testing.T) {
+	cli, fake := newFakeClient(t, "secret")
+	fake.packages = []*polygon.Package{{Id: 1, Revision: 9, State: polygon.PackageReady, Type: "standard"}}
+
+	if _, err := cli.LatestPackage(context.Background(), 100, true); !errors.Is(err, polygon.ErrPackageNotFound) {
+		t.Errorf("Expected ErrPackageNotFound, got %v", err)
+	}
+}
+
+func TestClient_ContestProblems(t *testing.T) {
+	cli, _ := newFakeClient(t, "secret")
+
+	problems, err := cli.ContestProblems(context.Background(), 1)
+	if err != nil {
+		t.Fatal("Unable to list contest problems:", err)
+	}
+
+	if len(problems) != 2 || problems["A"].Id != 100 || problems["B"].Revision != 5 {
+		t.Errorf("Contest problems are not correct: %+v", problems)
+	}
+}
+
+func TestClient_Problem(t *testing.T) {
+	cli, _ := newFakeClient(t, "secret")
+
+	problem, err := cli.Problem(context.Background(), 100)
+	if err != nil {
+		t.Fatal("Unable to get problem:", err)
+	}
+
+	if problem.Revision != 12 || problem.Name != "sum" {
+		t.Errorf("Problem is not correct: %+v", problem)
+	}
+
+	if _, err := cli.Problem(context.Background(), 200); !errors.Is(err, polygon.ErrProblemNotFound) {
+		t.Errorf("Expected ErrProblemNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package polygon
+
+import (
+	"github.com/eolymp/polyglot/cmd/httpx"
+	"time"
+)
+
+type Option func(*Client)
+
+// WithClient sets HTTP client used to communicate with Polygon
+func WithClient(client httpx.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithPollInterval sets how often package state is checked while waiting for a build
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// WithClock replaces time source and nonce generator used to sign requests
+func WithClock(now func() time.Time, nonce func() string) Option {
+	return func(c *Client) {
+		c.now = now
+		c.nonce = nonce
+	}
+}
@@ -0,0 +1,42 @@
+package polygon
+
+const (
+	PackagePending = "PENDING"
+	PackageRunning = "RUNNING"
+	PackageReady   = "READY"
+	PackageFailed  = "FAILED"
+)
+
+type ProblemInfo struct {
+	InputFile   string `json:"inputFile"`
+	OutputFile  string `json:"outputFile"`
+	Interactive bool   `json:"interactive"`
+	TimeLimit   int    `json:"timeLimit"`
+	MemoryLimit int    `json:"memoryLimit"`
+}
+
+type Problem struct {
+	Id            int    `json:"id"`
+	Owner         string `json:"owner"`
+	Name          string `json:"name"`
+	Deleted       bool   `json:"deleted"`
+	Favourite     bool   `json:"favourite"`
+	AccessType    string `json:"accessType"`
+	Revision      int    `json:"revision"`
+	LatestPackage int    `json:"latestPackage"`
+	Modified      bool   `json:"modified"`
+}
+
+type Package struct {
+	Id                  int    `json:"id"`
+	Revision            int    `json:"revision"`
+	CreationTimeSeconds int64  `json:"creationTimeSeconds"`
+	State               string `json:"state"`
+	Comment             string `json:"comment"`
+	Type                string `json:"type"`
+}
+
+// Full packages contain generated tests, standard ones do not
+func (p *Package) Full() bool {
+	return p.Type != "standard"
+}
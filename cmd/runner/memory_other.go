@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd
+
+package runner
+
+import "os"
+
+// peakMemory is not known on this platform
+func peakMemory(state *os.ProcessState) uint64 {
+	return 0
+}
+
+// limitMemory does not limit memory on this platform
+func limitMemory(binary string, args []string, memory uint64) (string, []string) {
+	return binary, args
+}
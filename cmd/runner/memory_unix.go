@@ -0,0 +1,36 @@
+//go:build linux || darwin || freebsd
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// peakMemory returns maximum resident set size of the finished process
+func peakMemory(state *os.ProcessState) uint64 {
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+
+	// ru_maxrss is in bytes on macOS and in kilobytes elsewhere
+	if runtime.GOOS == "darwin" {
+		return uint64(usage.Maxrss)
+	}
+
+	return uint64(usage.Maxrss) * 1024
+}
+
+// limitMemory returns the command which sets address space limit with ulimit and replaces the shell with the program,
+// the limit is not set on macOS, which ignores it
+func limitMemory(binary string, args []string, memory uint64) (string, []string) {
+	if memory == 0 || runtime.GOOS == "darwin" {
+		return binary, args
+	}
+
+	script := fmt.Sprintf(`ulimit -v %d && exec "$0" "$@"`, (memory+1023)/1024)
+	return "/bin/sh", append([]string{"-c", script, binary}, args...)
+}
@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCompiler = "g++"
+	DefaultTimeout  = time.Minute
+)
+
+var DefaultFlags = []string{"-O2", "-std=c++17"}
+
+// ErrTimeLimit is returned by Run when the program is killed after the timeout
+var ErrTimeLimit = errors.New("time limit exceeded")
+
+// ErrCompilation is returned by Compile when the compiler rejects the source
+var ErrCompilation = errors.New("compilation error")
+
+// Compiler builds C++ programs (validators, checkers, generators) on the local machine
+type Compiler struct {
+	command string
+	flags   []string
+}
+
+// NewCompiler returns compiler which runs the command with flags, g++ -O2 -std=c++17 is used by default
+func NewCompiler(command string, flags []string) *Compiler {
+	if command == "" {
+		command = DefaultCompiler
+	}
+
+	if len(flags) == 0 {
+		flags = DefaultFlags
+	}
+
+	return &Compiler{command: command, flags: flags}
+}
+
+// Compile builds the source into the binary, directory of the source and includes are added to the include path
+func (c *Compiler) Compile(ctx context.Context, source, binary string, includes ...string) error {
+	args := append([]string{}, c.flags...)
+	for _, dir := range append([]string{filepath.Dir(source)}, includes...) {
+		args = append(args, "-I", dir)
+	}
+	args = append(args, "-o", binary, source)
+
+	var output bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, c.command, args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return fmt.Errorf("unable to compile %v: %w (%v)\n%v", filepath.Base(source), ErrCompilation, err, strings.TrimSpace(output.String()))
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to compile %v: %w", filepath.Base(source), err)
+	}
+
+	return nil
+}
+
+// Result of the program run
+type Result struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+	Duration time.Duration
+	Memory   uint64 // peak resident memory in bytes, 0 if it is unknown
+}
+
+// Message returns stderr of the program, or stdout if stderr is empty
+func (r *Result) Message() string {
+	if msg := strings.TrimSpace(string(r.Stderr)); msg != "" {
+		return msg
+	}
+	return strings.TrimSpace(string(r.Stdout))
+}
+
+// Run runs the program with stdin, non-zero exit code is not an error and is returned in the result. The program is
+// killed after the timeout, DefaultTimeout is used if timeout is zero. Address space of the program is limited to
+// memory bytes where the system supports it, so allocations above the limit fail, there is no limit if memory is zero.
+func Run(ctx context.Context, binary string, args []string, stdin io.Reader, timeout time.Duration, memory uint64) (*Result, error) {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	command, args := limitMemory(binary, args, memory)
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	started := time.Now()
+	err := cmd.Run()
+
+	result := &Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes(), Duration: time.Since(started)}
+	if cmd.ProcessState != nil {
+		result.Memory = peakMemory(cmd.ProcessState)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return result, fmt.Errorf("%v is killed after %v: %w", filepath.Base(binary), timeout, ErrTimeLimit)
+	}
+
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		result.ExitCode = exit.ExitCode()
+		return result, nil
+	}
+
+	return result, err
+}
@@ -0,0 +1,128 @@
+package runner_test
+
+import (
+	"context"
+	"errors"
+	"github.com/eolymp/polyglot/cmd/runner"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func compile(t *testing.T, source string) string {
+	if _, err := exec.LookPath(runner.DefaultCompiler); err != nil {
+		t.Skip("C++ compiler is not available")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "header.h"), []byte("#define EXIT_CODE 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "main.cpp"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	binary := filepath.Join(dir, "main")
+	if err := runner.NewCompiler("", nil).Compile(context.Background(), filepath.Join(dir, "main.cpp"), binary); err != nil {
+		t.Fatal(err)
+	}
+
+	return binary
+}
+
+func TestRun(t *testing.T) {
+	binary := compile(t, `#include <iostream>
+#include "header.h"
+int main(int argc, char** argv) {
+	int a, b;
+	std::cin >> a >> b;
+	std::cout << a + b << std::endl;
+	std::cerr << argv[1] << std::endl;
+	return EXIT_CODE;
+}
+`)
+
+	result, err := runner.Run(context.Background(), binary, []string{"arg"}, strings.NewReader("1 2"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.ExitCode != 3 {
+		t.Errorf("Exit code is %v, expected 3", result.ExitCode)
+	}
+
+	if got := strings.TrimSpace(string(result.Stdout)); got != "3" {
+		t.Errorf("Stdout is %#v, expected \"3\"", got)
+	}
+
+	if got := result.Message(); got != "arg" {
+		t.Errorf("Message is %#v, expected \"arg\"", got)
+	}
+
+	if runtime.GOOS == "linux" && result.Memory == 0 {
+		t.Error("Peak memory is not measured")
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	binary := compile(t, "int main() { for (;;); }\n")
+
+	if _, err := runner.Run(context.Background(), binary, nil, nil, 100*time.Millisecond, 0); !errors.Is(err, runner.ErrTimeLimit) {
+		t.Errorf("Expected time limit error, got %v", err)
+	}
+}
+
+func TestRunMemoryLimit(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		t.Skip("Memory limit is not supported on " + runtime.GOOS)
+	}
+
+	binary := compile(t, `#include <cstdlib>
+#include <cstring>
+int main() {
+	char* data = (char*) std::malloc(256 << 20);
+	if (!data) return 7;
+	std::memset(data, 1, 256 << 20);
+	return 0;
+}
+`)
+
+	result, err := runner.Run(context.Background(), binary, nil, nil, 0, 64<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.ExitCode != 7 {
+		t.Errorf("Exit code is %v, expected allocation above the limit to fail", result.ExitCode)
+	}
+
+	result, err = runner.Run(context.Background(), binary, nil, nil, 0, 512<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.ExitCode != 0 {
+		t.Errorf("Exit code is %v, expected allocation below the limit to succeed", result.ExitCode)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	if _, err := exec.LookPath(runner.DefaultCompiler); err != nil {
+		t.Skip("C++ compiler is not available")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.cpp"), []byte("int main() { return x; }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runner.NewCompiler("", nil).Compile(context.Background(), filepath.Join(dir, "main.cpp"), filepath.Join(dir, "main"))
+	if !errors.Is(err, runner.ErrCompilation) || !strings.Contains(err.Error(), "main.cpp") {
+		t.Errorf("Expected compilation error, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Migrate creates the state from data.json and cache.json used by earlier versions. It does nothing if the state
+// file already exists, legacy files are left untouched.
+func (s *Store) Migrate(dataPath, cachePath string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	state, exists, err := s.read()
+	if err != nil || exists {
+		return false, err
+	}
+
+	data := map[string]interface{}{}
+	dataFound, err := readLegacy(dataPath, &data)
+	if err != nil {
+		return false, err
+	}
+
+	cache := map[string]string{}
+	cacheFound, err := readLegacy(cachePath, &cache)
+	if err != nil {
+		return false, err
+	}
+
+	if !dataFound && !cacheFound {
+		return false, nil
+	}
+
+	MigrateData(state, data)
+	MigrateCache(state, cache)
+
+	return true, s.write(state)
+}
+
+// MigrateData converts records of legacy data.json: problem links mapped to problem IDs, "package:<link>" package
+// states, "testsets:<problem>" testset positions, "contest:<id>" contest IDs and lists of contest problems.
+func MigrateData(state *State, data map[string]interface{}) {
+	for key, value := range data {
+		switch {
+		case strings.HasPrefix(key, "package:"):
+			var pkg struct {
+				ProblemId string `json:"id"`
+				Revision  int    `json:"revision"`
+				Hash      string `json:"hash"`
+			}
+
+			if remarshal(value, &pkg) != nil {
+				continue
+			}
+
+			p := state.Problem(strings.TrimPrefix(key, "package:"))
+			if p.ProblemId == "" {
+				p.ProblemId = pkg.ProblemId
+			}
+			p.Revision = pkg.Revision
+			p.Hash = pkg.Hash
+		case strings.HasPrefix(key, "testsets:"):
+			slots := map[string]uint32{}
+			if remarshal(value, &slots) != nil {
+				continue
+			}
+
+			state.Testsets[strings.TrimPrefix(key, "testsets:")] = slots
+		case strings.HasPrefix(key, "contest:"):
+			if id, ok := value.(string); ok {
+				state.Contest(strings.TrimPrefix(key, "contest:")).ContestId = id
+			}
+		default:
+			switch v := value.(type) {
+			case string:
+				state.Problem(key).ProblemId = v
+			case []interface{}:
+				var problems []map[string]interface{}
+				if remarshal(v, &problems) != nil {
+					continue
+				}
+
+				contest := state.Contest(key)
+				contest.Problems = nil
+				for _, problem := range problems {
+					contest.Problems = append(contest.Problems, &ContestProblem{
+						Link:      legacyString(problem["link"]),
+						ProblemId: legacyString(problem["id"]),
+						Index:     legacyString(problem["index"]),
+					})
+				}
+			}
+		}
+	}
+}
+
+// MigrateCache converts legacy cache.json, which maps SHA-1 of uploaded files to keeper keys. Endpoint and space of
+// these blobs are unknown, so they are not reused until uploaded again.
+func MigrateCache(state *State, cache map[string]string) {
+	for sha, key := range cache {
+		state.AddBlob(&Blob{Sha: sha, Key: key})
+	}
+}
+
+func readLegacy(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return true, nil
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("unable to parse %v: %w", path, err)
+	}
+
+	return true, nil
+}
+
+func remarshal(in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func legacyString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,86 @@
+package store_test
+
+import (
+	"github.com/eolymp/polyglot/cmd/store"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const legacyData = `{
+	"https://polygon.codeforces.com/p/user/problem": "p1",
+	"package:https://polygon.codeforces.com/p/user/problem": {"id": "p1", "revision": 7, "hash": "abc"},
+	"testsets:p1": {"tests": 0, "pretests": 1},
+	"contest:42": "c1",
+	"42": [{"id": "p1", "link": "https://polygon.codeforces.com/p/user/problem", "index": "A"}]
+}`
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data.json")
+	cache := filepath.Join(dir, "cache.json")
+
+	if err := os.WriteFile(data, []byte(legacyData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(cache, []byte(`{"sha": "key"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := store.New(filepath.Join(dir, "state.json"))
+
+	migrated, err := st.Migrate(data, cache)
+	if err != nil {
+		t.Fatal("Migration has failed:", err)
+	}
+
+	if !migrated {
+		t.Fatal("Legacy files must be migrated")
+	}
+
+	err = st.View(func(state *store.State) error {
+		p := state.Problems["https://polygon.codeforces.com/p/user/problem"]
+		if p == nil || p.ProblemId != "p1" || p.Revision != 7 || p.Hash != "abc" {
+			t.Errorf("Problem is not migrated correctly: %+v", p)
+		}
+
+		if slots := state.Testsets["p1"]; slots["tests"] != 0 || slots["pretests"] != 1 {
+			t.Errorf("Testset positions are not migrated correctly: %v", slots)
+		}
+
+		c := state.Contests["42"]
+		if c == nil || c.ContestId != "c1" || len(c.Problems) != 1 || c.Problems[0].ProblemId != "p1" || c.Problems[0].Index != "A" {
+			t.Errorf("Contest is not migrated correctly: %+v", c)
+		}
+
+		if b := state.Blobs["sha"]; b == nil || b.Key != "key" {
+			t.Errorf("Blob cache is not migrated correctly: %+v", b)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal("View has failed:", err)
+	}
+
+	// second run does nothing
+	migrated, err = st.Migrate(data, cache)
+	if err != nil || migrated {
+		t.Errorf("Migration must run only once, got migrated = %v, err = %v", migrated, err)
+	}
+}
+
+func TestMigrateWithoutLegacyFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	migrated, err := store.New(path).Migrate(filepath.Join(dir, "data.json"), filepath.Join(dir, "cache.json"))
+	if err != nil || migrated {
+		t.Fatalf("Nothing must be migrated, got migrated = %v, err = %v", migrated, err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		t.Errorf("State file must not be created")
+	}
+}
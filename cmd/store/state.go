@@ -0,0 +1,174 @@
+package store
+
+import "time"
+
+// Version of the state file format
+const Version = 1
+
+// HistoryLimit is the number of import records kept in the state
+const HistoryLimit = 1000
+
+// State is the content of the local state file
+type State struct {
+	Version  int                          `json:"version"`
+	Problems map[string]*Problem          `json:"problems"`
+	Contests map[string]*Contest          `json:"contests"`
+	Testsets map[string]map[string]uint32 `json:"testsets"`
+	Blobs    map[string]*Blob             `json:"blobs"`
+	Assets   map[string]*Blob             `json:"assets"`
+	History  []*Import                    `json:"history"`
+}
+
+// Problem maps problem source (Polygon link or ID) to Eolymp problem and describes the last imported package
+type Problem struct {
+	ProblemId  string    `json:"problem_id"`
+	Revision   int       `json:"revision,omitempty"`
+	Hash       string    `json:"hash,omitempty"`
+	ImportedAt time.Time `json:"imported_at,omitempty"`
+}
+
+// Unchanged reports whether the package with given revision and hash was already imported
+func (p *Problem) Unchanged(revision int, hash string) bool {
+	if p.Revision != 0 && p.Revision == revision {
+		return true
+	}
+	return p.Hash != "" && p.Hash == hash
+}
+
+// Contest maps Polygon contest (or ejudge contest directory) to Eolymp contest and its problems
+type Contest struct {
+	ContestId string            `json:"contest_id,omitempty"`
+	Problems  []*ContestProblem `json:"problems"`
+}
+
+type ContestProblem struct {
+	Link      string `json:"link"`
+	ProblemId string `json:"problem_id"`
+	Index     string `json:"index,omitempty"`
+}
+
+// Blob is an object uploaded to keeper. Blobs are identified by SHA-1 of the content and scoped by API endpoint and
+// space, blobs migrated from cache.json have no scope. Typewriter assets (statement pictures and files) are kept as
+// blobs too, their key is the link of the asset.
+type Blob struct {
+	Endpoint   string    `json:"endpoint,omitempty"`
+	Space      string    `json:"space,omitempty"`
+	Sha        string    `json:"sha"`
+	Key        string    `json:"key"`
+	Size       int64     `json:"size,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at,omitempty"`
+	VerifiedAt time.Time `json:"verified_at,omitempty"`
+}
+
+// Scoped reports whether the blob belongs to the endpoint and space
+func (b *Blob) Scoped(endpoint, space string) bool {
+	return b.Endpoint != "" && b.Endpoint == endpoint && b.Space == space
+}
+
+// BlobKey returns key of the blob in State.Blobs
+func BlobKey(endpoint, space, sha string) string {
+	if endpoint == "" {
+		return sha
+	}
+	return endpoint + "/spaces/" + space + "#" + sha
+}
+
+// Import records a single problem import
+type Import struct {
+	Source    string    `json:"source"`
+	ProblemId string    `json:"problem_id"`
+	Format    string    `json:"format,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  float64   `json:"duration"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func NewState() *State {
+	s := &State{}
+	s.init()
+	return s
+}
+
+func (s *State) init() {
+	if s.Version == 0 {
+		s.Version = Version
+	}
+	if s.Problems == nil {
+		s.Problems = map[string]*Problem{}
+	}
+	if s.Contests == nil {
+		s.Contests = map[string]*Contest{}
+	}
+	if s.Testsets == nil {
+		s.Testsets = map[string]map[string]uint32{}
+	}
+	if s.Blobs == nil {
+		s.Blobs = map[string]*Blob{}
+	}
+	if s.Assets == nil {
+		s.Assets = map[string]*Blob{}
+	}
+	for key, blob := range s.Blobs {
+		if blob.Sha == "" && blob.Endpoint == "" {
+			blob.Sha = key
+		}
+	}
+}
+
+// Problem returns record for the problem source, the record is created if it does not exist
+func (s *State) Problem(link string) *Problem {
+	p, ok := s.Problems[link]
+	if !ok {
+		p = &Problem{}
+		s.Problems[link] = p
+	}
+	return p
+}
+
+// Contest returns record for the Polygon contest, the record is created if it does not exist
+func (s *State) Contest(id string) *Contest {
+	c, ok := s.Contests[id]
+	if !ok {
+		c = &Contest{}
+		s.Contests[id] = c
+	}
+	return c
+}
+
+// Blob returns blob uploaded to the endpoint and space
+func (s *State) Blob(endpoint, space, sha string) (*Blob, bool) {
+	b, ok := s.Blobs[BlobKey(endpoint, space, sha)]
+	if !ok || !b.Scoped(endpoint, space) {
+		return nil, false
+	}
+	return b, true
+}
+
+func (s *State) AddBlob(blob *Blob) {
+	s.Blobs[BlobKey(blob.Endpoint, blob.Space, blob.Sha)] = blob
+}
+
+func (s *State) RemoveBlob(blob *Blob) {
+	delete(s.Blobs, BlobKey(blob.Endpoint, blob.Space, blob.Sha))
+}
+
+// Asset returns typewriter asset uploaded to the endpoint and space
+func (s *State) Asset(endpoint, space, sha string) (*Blob, bool) {
+	a, ok := s.Assets[BlobKey(endpoint, space, sha)]
+	if !ok || !a.Scoped(endpoint, space) {
+		return nil, false
+	}
+	return a, true
+}
+
+func (s *State) AddAsset(asset *Blob) {
+	s.Assets[BlobKey(asset.Endpoint, asset.Space, asset.Sha)] = asset
+}
+
+// AddImport appends import record to the history, only the last HistoryLimit records are kept
+func (s *State) AddImport(record *Import) {
+	s.History = append(s.History, record)
+	if len(s.History) > HistoryLimit {
+		s.History = s.History[len(s.History)-HistoryLimit:]
+	}
+}
@@ -0,0 +1,269 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store keeps local state in a JSON file. The state is kept in memory and the file is only read again when another
+// store (for example, the Telegram bot and a CLI run) replaces it, so the same state can be shared by concurrent
+// goroutines and processes. The file is locked while it is read or written.
+type Store struct {
+	path string
+	mu   sync.Mutex
+
+	state   *State
+	stamp   os.FileInfo          // the file the state was read from, nil if it did not exist
+	pending []func(*State) error // deferred changes which are not saved yet
+	flushed time.Time
+}
+
+const (
+	FlushSize     = 100 // deferred changes are saved when there are this many of them
+	FlushInterval = 10 * time.Second
+)
+
+func New(path string) *Store {
+	return &Store{path: path, flushed: time.Now()}
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// View calls fn with the current state under a shared lock, fn must not modify the state
+func (s *Store) View(fn func(state *State) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock(false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	return fn(s.state)
+}
+
+// Update calls fn with the current state under an exclusive lock and saves the state if fn succeeds, deferred
+// changes are saved together with it
+func (s *Store) Update(fn func(state *State) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	if err := fn(s.state); err != nil {
+		// the state might be changed partially, so it is read again next time
+		s.state = nil
+		return err
+	}
+
+	return s.save()
+}
+
+// Defer applies fn to the state in memory and saves it later in a batch with other changes. It is meant for
+// changes which are cheap to lose, like cache entries. Deferred changes are saved by Flush, by the next Update, or
+// when there are FlushSize of them or FlushInterval has passed.
+func (s *Store) Defer(fn func(state *State) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock(false)
+	if err != nil {
+		return err
+	}
+
+	err = s.load()
+	unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.state); err != nil {
+		s.state = nil
+		return err
+	}
+
+	s.pending = append(s.pending, fn)
+
+	if len(s.pending) < FlushSize && time.Since(s.flushed) < FlushInterval {
+		return nil
+	}
+
+	return s.flush()
+}
+
+// Flush saves deferred changes, it has to be called before the program exits
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	return s.flush()
+}
+
+func (s *Store) flush() error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	return s.save()
+}
+
+// load reads the file if it was replaced since the state was read, deferred changes are applied to the new state
+func (s *Store) load() error {
+	stamp, err := os.Stat(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if s.state != nil && sameStamp(s.stamp, stamp) {
+		return nil
+	}
+
+	state, _, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	for _, fn := range s.pending {
+		if err := fn(state); err != nil {
+			return err
+		}
+	}
+
+	s.state, s.stamp = state, stamp
+
+	return nil
+}
+
+// save writes the state and forgets deferred changes, the caller holds the exclusive lock
+func (s *Store) save() error {
+	if err := s.write(s.state); err != nil {
+		s.state = nil
+		return err
+	}
+
+	stamp, err := os.Stat(s.path)
+	if err != nil {
+		s.state = nil
+		return err
+	}
+
+	s.stamp = stamp
+	s.pending = nil
+	s.flushed = time.Now()
+
+	return nil
+}
+
+// sameStamp reports whether both stamps describe the same version of the file, the file is replaced on every write,
+// so a new version is a new file
+func sameStamp(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+func (s *Store) lock(exclusive bool) (func(), error) {
+	file, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open lock file: %w", err)
+	}
+
+	if err := lockFile(file, exclusive); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("unable to lock state: %w", err)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+	}, nil
+}
+
+// read loads the state file, an empty state is returned if the file does not exist
+func (s *Store) read() (*State, bool, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewState(), false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, true, fmt.Errorf("unable to parse %v: %w", s.path, err)
+	}
+
+	if state.Version > Version {
+		return nil, true, fmt.Errorf("%v has unsupported version %v", s.path, state.Version)
+	}
+
+	state.init()
+
+	return state, true, nil
+}
+
+// write replaces the state file atomically, so the file is never left half-written
+func (s *Store) write(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,174 @@
+package store_test
+
+import (
+	"errors"
+	"github.com/eolymp/polyglot/cmd/store"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestStoreUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	st := store.New(path)
+
+	err := st.Update(func(state *store.State) error {
+		state.Problem("123").ProblemId = "abc"
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Update has failed:", err)
+	}
+
+	// the change is visible to another store using the same file
+	err = store.New(path).View(func(state *store.State) error {
+		if got := state.Problems["123"].ProblemId; got != "abc" {
+			t.Errorf("Problem ID does not match: want %#v, got %#v", "abc", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("View has failed:", err)
+	}
+}
+
+func TestStoreUpdateError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	st := store.New(path)
+
+	failure := errors.New("failure")
+	err := st.Update(func(state *store.State) error {
+		state.Problem("123").ProblemId = "abc"
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Update must return error of the callback, got %v", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("State must not be saved when callback fails")
+	}
+}
+
+func TestStoreConcurrentUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// every goroutine uses its own store to share the file like separate processes do
+			err := store.New(path).Update(func(state *store.State) error {
+				state.Blobs[strconv.Itoa(i)] = &store.Blob{Key: "key"}
+				return nil
+			})
+			if err != nil {
+				t.Error("Update has failed:", err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	err := store.New(path).View(func(state *store.State) error {
+		if len(state.Blobs) != 20 {
+			t.Errorf("Some updates are lost: want 20 blobs, got %v", len(state.Blobs))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("View has failed:", err)
+	}
+
+	files, _ := filepath.Glob(path + ".*.tmp")
+	if len(files) != 0 {
+		t.Errorf("Temporary files are left: %v", files)
+	}
+}
+
+func TestStoreDefer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	st := store.New(path)
+
+	err := st.Defer(func(state *store.State) error {
+		state.Blobs["sha"] = &store.Blob{Key: "key"}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Defer has failed:", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Deferred change must not be saved before Flush")
+	}
+
+	// another process changes the file in the meantime
+	err = store.New(path).Update(func(state *store.State) error {
+		state.Problem("123").ProblemId = "abc"
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Update has failed:", err)
+	}
+
+	err = st.View(func(state *store.State) error {
+		if state.Problems["123"] == nil || state.Blobs["sha"] == nil {
+			t.Errorf("State must contain both the new file and the deferred change")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("View has failed:", err)
+	}
+
+	if err := st.Flush(); err != nil {
+		t.Fatal("Flush has failed:", err)
+	}
+
+	err = store.New(path).View(func(state *store.State) error {
+		if state.Problems["123"] == nil || state.Blobs["sha"] == nil {
+			t.Errorf("Flush must keep changes of the other process and save the deferred one")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("View has failed:", err)
+	}
+}
+
+func TestStoreHistoryLimit(t *testing.T) {
+	state := store.NewState()
+	for i := 0; i < store.HistoryLimit+10; i++ {
+		state.AddImport(&store.Import{Source: strconv.Itoa(i)})
+	}
+
+	if len(state.History) != store.HistoryLimit {
+		t.Fatalf("History must be limited to %v records, got %v", store.HistoryLimit, len(state.History))
+	}
+
+	if got := state.History[0].Source; got != "10" {
+		t.Errorf("Oldest records must be removed first, got %#v as the first record", got)
+	}
+}
+
+func TestStateBlobScope(t *testing.T) {
+	state := store.NewState()
+	state.AddBlob(&store.Blob{Endpoint: "https://api.eolymp.com", Space: "a", Sha: "sha", Key: "key-a"})
+	state.AddBlob(&store.Blob{Sha: "sha", Key: "legacy"})
+
+	if b, ok := state.Blob("https://api.eolymp.com", "a", "sha"); !ok || b.Key != "key-a" {
+		t.Errorf("Blob of space a is not found: %+v", b)
+	}
+
+	if b, ok := state.Blob("https://api.eolymp.com", "b", "sha"); ok {
+		t.Errorf("Blob of space a must not be returned for space b: %+v", b)
+	}
+
+	if b, ok := state.Blob("", "", "sha"); ok {
+		t.Errorf("Blobs without scope must not be returned: %+v", b)
+	}
+}